@@ -0,0 +1,387 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Exit codes returned by the command-line mode
+const (
+	ExitOK       = 0   // Everything was created
+	ExitFailure  = 1   // Folder generation failed
+	ExitUsage    = 2   // Wrong subcommand or flags
+	ExitBadInput = 3   // The table file could not be loaded
+	ExitCanceled = 130 // Interrupted with Ctrl+C
+)
+
+// CLI runs Folder Creator without a display
+type CLI struct {
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Create new CLI instance writing to the standard streams
+func NewCLI() *CLI {
+	return &CLI{Stdout: os.Stdout, Stderr: os.Stderr}
+}
+
+// Subcommands and help flags that start the command-line mode
+var cliCommands = []string{"create", "undo", "help", "-h", "-help", "--help"}
+
+// Arguments for the command-line mode, or nil to open the window
+// The -psn_ argument macOS adds when the app is opened from the Finder is dropped,
+// and so is anything else that does not start with a subcommand.
+func CLIArgs(args []string) []string {
+	var kept []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-psn_") {
+			kept = append(kept, arg)
+		}
+	}
+	if len(kept) == 0 || !slices.Contains(cliCommands, kept[0]) {
+		return nil
+	}
+	return kept
+}
+
+// Run the subcommand in args and return the exit code
+func (c *CLI) Run(args []string) int {
+	if len(args) == 0 {
+		c.PrintUsage()
+		return ExitUsage
+	}
+	switch args[0] {
+	case "create":
+		return c.RunCreate(args[1:])
+	case "undo":
+		return c.RunUndo(args[1:])
+	case "help", "-h", "-help", "--help":
+		c.PrintUsage()
+		return ExitOK
+	default:
+		fmt.Fprintf(c.Stderr, "unknown command: %s\n\n", args[0])
+		c.PrintUsage()
+		return ExitUsage
+	}
+}
+
+// Print the list of subcommands
+func (c *CLI) PrintUsage() {
+	fmt.Fprint(c.Stderr, `Usage:
+  folder-creator                      start the graphical interface
+  folder-creator create [flags]       create folders from a table file
+  folder-creator undo [flags]         remove the folders created by the last run
+  folder-creator help                 show this message
+
+Run "folder-creator <command> -h" to see the flags of a command.
+`)
+}
+
+// Load the table and create folders, printing a summary
+func (c *CLI) RunCreate(args []string) int {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	fs.SetOutput(c.Stderr)
+	table := fs.String("table", "", "table file to read folder names from (.csv, .xlsx, .xls, .ods, .json, .yaml or an indented .txt outline)")
+	dest := fs.String("dest", "", "target path where the folders are created")
+	sheet := fs.String("sheet", "", "comma-separated sheets to read from a workbook, \"*\" for all (default: the first sheet)")
+	sheetFolders := fs.Bool("sheet-folders", false, "create a top-level folder for each sheet instead of merging them")
+	header := fs.String("header", "auto", "whether the first row holds column titles: auto, yes or no")
+	roles := fs.String("roles", "", "comma-separated role of each column: top, sub, nested, ignore, meta or files (default: from -mode or the saved mapping)")
+	mode := fs.String("mode", "flat", "flat: columns B, C... are subfolders of column A; nested: each column is inside the previous one")
+	profile := fs.String("profile", DefaultNameProfile().String(), "name rules to check against: posix, windows, macos or portable")
+	fixNames := fs.Bool("fix", false, "fix invalid names instead of rejecting them")
+	keepGoing := fs.Bool("continue", false, "keep creating folders after a failure")
+	report := fs.String("report", "", "write the failed rows to this CSV file")
+	showProgress := fs.Bool("progress", false, "print progress to stderr while working")
+	encoding := fs.String("encoding", "auto", "character set of a CSV file: auto, utf-8, utf-16le, utf-16be, windows-1252, gbk, big5, shift-jis or euc-kr")
+	delimiter := fs.String("delimiter", "auto", "field delimiter of a CSV file: auto, comma, semicolon, tab or pipe")
+	quote := fs.String("quote", "auto", "quote character of a CSV file: auto, double, single or none")
+	comment := fs.String("comment", "none", "skip CSV lines starting with this character: none, # or auto to detect it")
+	namePattern := fs.String("name", "", "pattern for the top-level names, e.g. '{{.Col \"Code\"}}_{{.Col \"B\" | upper}}' (default: the cell value)")
+	expandLimit := fs.Int("expand-limit", DefaultExpandLimit, "most names a cell like \"Q{1..4}\" or \"{a,b}\", and folders and files a row, may expand to, 0 to keep braces as they are")
+	template := fs.String("template", "", "bundled template ("+strings.Join(BundledTemplateNames(), ", ")+") or folder copied into each top-level folder")
+	templateConflict := fs.String("template-conflict", "skip", "what to do with template files already in a folder: skip, overwrite or rename")
+	fileTemplate := fs.String("file-template", "", "text file rendered into each file of a files column, e.g. '# {{.Folder}}' (default: empty files)")
+	keepFile := fs.String("keep", "none", "placeholder file put into every empty folder: none, keep or gitkeep")
+	stream := fs.Bool("stream", false, "read the table from the file as needed, whatever its size")
+	dryRun := fs.Bool("dry-run", false, "print the plan without creating anything")
+	syncTree := fs.Bool("sync", false, "compare the table with the folders already under the target path and list the missing and unexpected ones")
+	archive := fs.Bool("archive", false, "with -sync, move the unexpected folders into "+ArchiveFolderName+"/<date> under the target path")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+	if *table == "" || *dest == "" {
+		fmt.Fprintln(c.Stderr, "create: both -table and -dest are required")
+		fs.Usage()
+		return ExitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(c.Stderr, "create: unexpected arguments: %v\n", fs.Args())
+		return ExitUsage
+	}
+
+	folderMode, err := ParseFolderMode(*mode)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "create: %v\n", err)
+		return ExitUsage
+	}
+	nameProfile, err := ParseNameProfile(*profile)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "create: %v\n", err)
+		return ExitUsage
+	}
+	csvEncoding, err := ParseEncoding(*encoding)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "create: %v\n", err)
+		return ExitUsage
+	}
+	dialect, err := ParseDialect(*delimiter, *quote, *comment)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "create: %v\n", err)
+		return ExitUsage
+	}
+	conflict, err := ParseTemplateConflict(*templateConflict)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "create: %v\n", err)
+		return ExitUsage
+	}
+	if *namePattern != "" {
+		if _, err := ParseNamePattern(*namePattern); err != nil {
+			fmt.Fprintf(c.Stderr, "create: %v\n", err)
+			return ExitUsage
+		}
+	}
+	keep, err := ParseKeepFile(*keepFile)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "create: %v\n", err)
+		return ExitUsage
+	}
+	p := NewFileProcessor()
+	p.NamePattern = *namePattern
+	p.ExpandLimit = max(*expandLimit, 0)
+	p.Template = *template
+	p.TemplateConflict = conflict
+	p.FileTemplate = *fileTemplate
+	p.KeepFile = keep
+	p.Mode = folderMode
+	p.Profile = nameProfile
+	p.AutoFix = *fixNames
+	p.Encoding = csvEncoding
+	p.Dialect = dialect
+	if *sheet != "" {
+		for _, name := range strings.Split(*sheet, ",") {
+			p.Sheets = append(p.Sheets, strings.TrimSpace(name))
+		}
+	}
+	if *sheetFolders {
+		p.SheetMode = SheetFolders
+	}
+	p.ContinueOnError = *keepGoing
+	if *stream {
+		p.StreamThreshold = 1
+	}
+	if err := p.LoadFile(*table); err != nil {
+		fmt.Fprintf(c.Stderr, "Failed to load: %v\n", err)
+		return ExitBadInput
+	}
+	if p.NumRows() == 0 {
+		fmt.Fprintln(c.Stderr, "No available data!")
+		return ExitBadInput
+	}
+	switch strings.ToLower(*header) {
+	case "auto":
+		if p.HasHeader {
+			fmt.Fprintln(c.Stdout, "Row 1 treated as header (use -header no to keep it)")
+		}
+	case "yes":
+		p.HasHeader = true
+	case "no":
+		p.HasHeader = false
+	default:
+		fmt.Fprintf(c.Stderr, "create: -header must be auto, yes or no\n")
+		return ExitUsage
+	}
+	if *roles != "" {
+		p.ColumnRoles = nil
+		for _, name := range strings.Split(*roles, ",") {
+			role, err := ParseColumnRole(name)
+			if err != nil {
+				fmt.Fprintf(c.Stderr, "create: %v\n", err)
+				return ExitUsage
+			}
+			p.ColumnRoles = append(p.ColumnRoles, role)
+		}
+	}
+	info, err := os.Stat(*dest)
+	if err != nil || !info.IsDir() {
+		fmt.Fprintf(c.Stderr, "Target path is not a folder: %s\n", *dest)
+		return ExitUsage
+	}
+	// Absolute paths keep the journal usable from any working directory
+	if p.DestPath, err = filepath.Abs(*dest); err != nil {
+		fmt.Fprintf(c.Stderr, "Wrong target path: %v\n", err)
+		return ExitUsage
+	}
+
+	// Ctrl+C stops the run, leaving the remaining folders pending
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	var progress ProgressFunc
+	if *showProgress {
+		progress = c.PrintProgress
+	}
+	plan, err := p.BuildPlanContext(ctx, progress)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		if ctx.Err() != nil {
+			return ExitCanceled
+		}
+		return ExitFailure
+	}
+	fmt.Fprintf(c.Stdout, "Loaded %d rows from %s\n", p.NumRows(), *table)
+	for _, warning := range plan.Warnings {
+		fmt.Fprintln(c.Stderr, "Warning: "+warning)
+	}
+	if *syncTree || *archive {
+		report, err := CompareTree(ctx, plan)
+		if err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+			if ctx.Err() != nil {
+				return ExitCanceled
+			}
+			return ExitFailure
+		}
+		c.PrintSyncReport(report)
+		if *archive && !*dryRun {
+			archived := report.ArchiveUnexpected(time.Now())
+			fmt.Fprintln(c.Stdout, "Archive: "+archived.Summary())
+			for _, err := range archived.Errors {
+				fmt.Fprintf(c.Stderr, "Failed to archive %v\n", err)
+			}
+		}
+	}
+	if *dryRun {
+		c.PrintPlan(plan)
+		return ExitOK
+	}
+	for _, op := range plan.Renamed() {
+		fmt.Fprintf(c.Stdout, "row %d, column %s: %q renamed to %q\n", op.Row+1, ColumnName(op.Col), op.Original, op.Name)
+	}
+	result := p.RunPlanContext(ctx, plan, progress)
+	if *showProgress {
+		fmt.Fprintln(c.Stderr)
+	}
+	fmt.Fprintf(c.Stdout, "Created %d folder(s) in %s\n", result.Created, p.DestPath)
+	if len(result.Copied) > 0 {
+		fmt.Fprintf(c.Stdout, "Copied %d file(s) and folder(s) from the template\n", len(result.Copied))
+	}
+	if result.Files > 0 {
+		fmt.Fprintf(c.Stdout, "Created %d file(s)\n", result.Files)
+	}
+	if len(result.Placeholders) > 0 {
+		fmt.Fprintf(c.Stdout, "Put %s into %d empty folder(s)\n", p.KeepFile, len(result.Placeholders))
+	}
+	failures := result.Failures()
+	for _, res := range failures {
+		if res.Status == ResultPending {
+			continue
+		}
+		fmt.Fprintf(c.Stderr, "row %d, column %s: %s [%s] %s\n",
+			res.Row+1, ColumnName(res.Col), res.Path, res.Status, res.Error)
+	}
+	if result.JournalErr != nil {
+		fmt.Fprintf(c.Stderr, "Failed to write the journal, this run cannot be undone: %v\n", result.JournalErr)
+	}
+	if *report != "" {
+		if err := result.SaveFailuresCSV(*report); err != nil {
+			fmt.Fprintf(c.Stderr, "Failed to write report: %v\n", err)
+		}
+	}
+	if result.Cancelled {
+		fmt.Fprintf(c.Stderr, "Cancelled, %d folder(s) not created\n", len(failures))
+		return ExitCanceled
+	}
+	if len(failures) > 0 {
+		fmt.Fprintf(c.Stderr, "%d folder(s) not created\n", len(failures))
+		return ExitFailure
+	}
+	return ExitOK
+}
+
+// Print the progress on a single line of stderr
+func (c *CLI) PrintProgress(pr Progress) {
+	fmt.Fprintf(c.Stderr, "\r%-70s", pr.String())
+}
+
+// Print every operation of the plan followed by the summary
+func (c *CLI) PrintPlan(plan *Plan) {
+	for _, op := range plan.Operations {
+		line := fmt.Sprintf("row %-5d %-9s %s", op.Row+1, op.KindLabel(), plan.RelPath(op))
+		if op.Path == "" {
+			line = fmt.Sprintf("row %-5d %-9s", op.Row+1, op.Kind)
+		}
+		if op.Reason != "" {
+			line += "  (" + op.Reason + ")"
+		}
+		fmt.Fprintln(c.Stdout, line)
+	}
+	fmt.Fprintln(c.Stdout, "Plan: "+plan.Summary())
+}
+
+// Print the missing and unexpected folders of the comparison
+func (c *CLI) PrintSyncReport(report *SyncReport) {
+	for _, entry := range report.Entries {
+		if entry.Status != SyncPresent {
+			fmt.Fprintf(c.Stdout, "%-10s %s\n", entry.Status, report.RelPath(entry))
+		}
+	}
+	fmt.Fprintln(c.Stdout, "Sync: "+report.Summary())
+}
+
+// Remove the folders created by the last run
+func (c *CLI) RunUndo(args []string) int {
+	fs := flag.NewFlagSet("undo", flag.ContinueOnError)
+	fs.SetOutput(c.Stderr)
+	journal := fs.String("journal", DefaultJournalPath(), "journal file written by the run to undo")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+	if *journal == "" {
+		fmt.Fprintln(c.Stderr, "undo: no journal file")
+		return ExitUsage
+	}
+	result, err := UndoJournalFile(*journal)
+	if result == nil {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", err)
+		return ExitBadInput
+	}
+	for _, path := range result.Kept {
+		fmt.Fprintf(c.Stderr, "kept %s\n", path)
+	}
+	for _, e := range result.Errors {
+		fmt.Fprintf(c.Stderr, "Error: %v\n", e)
+	}
+	fmt.Fprintln(c.Stdout, "Undo: "+result.Summary())
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Failed to update the journal: %v\n", err)
+		return ExitFailure
+	}
+	if len(result.Kept) > 0 {
+		return ExitFailure
+	}
+	return ExitOK
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCLIArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"no arguments", nil, nil},
+		{"finder launch", []string{"-psn_0_12345"}, nil},
+		{"file to open", []string{"/Users/me/table.xlsx"}, nil},
+		{"unknown flag", []string{"-v"}, nil},
+		{"create", []string{"create", "-table", "t.csv"}, []string{"create", "-table", "t.csv"}},
+		{"undo", []string{"undo"}, []string{"undo"}},
+		{"help", []string{"--help"}, []string{"--help"}},
+		{"short help", []string{"-h"}, []string{"-h"}},
+		{"finder argument dropped", []string{"-psn_0_1", "undo"}, []string{"undo"}},
+		{"subcommand not first", []string{"-table", "t.csv", "create"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CLIArgs(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CLIArgs(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
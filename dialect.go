@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DialectAuto marks a part of the CSV dialect that is detected from the file
+const DialectAuto rune = -1
+
+// CSVDialect describes how the fields of a CSV file are written
+type CSVDialect struct {
+	Comma   rune // Field delimiter
+	Quote   rune // Quote character, 0 for none
+	Comment rune // Lines starting with it are skipped, 0 for none
+}
+
+// Dialect with the delimiter and quote detected from the file
+// Comment lines are only detected on request: a wrong guess would drop rows.
+func AutoDialect() CSVDialect {
+	return CSVDialect{Comma: DialectAuto, Quote: DialectAuto}
+}
+
+// Dialect of text copied from a spreadsheet
+var tabDialect = CSVDialect{Comma: '\t', Quote: '"'}
+
+// Choices for each part of the dialect, in the order shown in the UI
+var (
+	DelimiterNames = []string{"Auto", "Comma", "Semicolon", "Tab", "Pipe"}
+	delimiterRunes = []rune{DialectAuto, ',', ';', '\t', '|'}
+	QuoteNames     = []string{"Auto", "Double quote", "Single quote", "None"}
+	quoteRunes     = []rune{DialectAuto, '"', '\'', 0}
+	CommentNames   = []string{"Auto", "None", "#"}
+	commentRunes   = []rune{DialectAuto, 0, '#'}
+)
+
+// Name of a dialect choice
+func dialectName(names []string, runes []rune, r rune) string {
+	for i, c := range runes {
+		if c == r {
+			return names[i]
+		}
+	}
+	return fmt.Sprintf("%q", r)
+}
+
+// Get a dialect choice from its name or the character itself, ignoring case
+func parseDialectRune(names []string, runes []rune, name string) (rune, error) {
+	if name == `\t` {
+		name = "\t"
+	}
+	for i, n := range names {
+		if strings.EqualFold(n, name) || (len(name) == 1 && runes[i] == rune(name[0])) {
+			return runes[i], nil
+		}
+	}
+	return DialectAuto, fmt.Errorf("unknown choice: %s (expected one of %s)", name, strings.Join(names, ", "))
+}
+
+// Get the dialect from the names of its delimiter, quote and comment
+// "double" and "single" are short for the quote names.
+func ParseDialect(delimiter, quote, comment string) (CSVDialect, error) {
+	var d CSVDialect
+	var err error
+	if d.Comma, err = parseDialectRune(DelimiterNames, delimiterRunes, delimiter); err != nil {
+		return d, fmt.Errorf("delimiter: %v", err)
+	}
+	if strings.EqualFold(quote, "double") || strings.EqualFold(quote, "single") {
+		quote += " quote"
+	}
+	if d.Quote, err = parseDialectRune(QuoteNames, quoteRunes, quote); err != nil {
+		return d, fmt.Errorf("quote: %v", err)
+	}
+	if d.Comment, err = parseDialectRune(CommentNames, commentRunes, comment); err != nil {
+		return d, fmt.Errorf("comment: %v", err)
+	}
+	return d, nil
+}
+
+// Names of the delimiter, quote and comment of the dialect
+func (d CSVDialect) String() string {
+	return fmt.Sprintf("%s, %s, comments %s",
+		dialectName(DelimiterNames, delimiterRunes, d.Comma),
+		strings.ToLower(dialectName(QuoteNames, quoteRunes, d.Quote)),
+		strings.ToLower(dialectName(CommentNames, commentRunes, d.Comment)))
+}
+
+// Detect the dialect of a CSV file, returning it with the start of the file as text
+func (p *FileProcessor) SniffCSVFile(filePath string) (CSVDialect, string, error) {
+	text, file, _, _, err := p.openCSVText(filePath)
+	if err != nil {
+		return CSVDialect{}, "", err
+	}
+	defer file.Close()
+	sample := make([]byte, encodingSampleSize)
+	n, err := io.ReadFull(text, sample)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return CSVDialect{}, "", err
+	}
+	return SniffDialect(string(sample[:n]), AutoDialect()), string(sample[:n]), nil
+}
+
+// Lines of the sample looked at to detect the dialect
+const sniffLines = 50
+
+// Fill the Auto parts of the dialect from a sample of the file
+func SniffDialect(sample string, want CSVDialect) CSVDialect {
+	lines := strings.Split(strings.ReplaceAll(sample, "\r\n", "\n"), "\n")
+	// The last line may be cut
+	if len(lines) > 1 {
+		lines = lines[:len(lines)-1]
+	}
+	var filled []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			filled = append(filled, line)
+		}
+		if len(filled) == sniffLines {
+			break
+		}
+	}
+	d := want
+	if d.Comment == DialectAuto {
+		d.Comment = sniffComment(filled)
+	}
+	var data []string
+	for _, line := range filled {
+		if d.Comment == 0 || !strings.HasPrefix(line, string(d.Comment)) {
+			data = append(data, line)
+		}
+	}
+	if d.Quote == DialectAuto {
+		d.Quote = sniffQuote(data)
+	}
+	if d.Comma == DialectAuto {
+		d.Comma = sniffDelimiter(data, d.Quote)
+	}
+	return d
+}
+
+// Comment character used at the start of some lines but not all of them
+func sniffComment(lines []string) rune {
+	comments := 0
+	for _, line := range lines {
+		if strings.HasPrefix(line, "#") {
+			comments++
+		}
+	}
+	if comments > 0 && comments < len(lines) {
+		return '#'
+	}
+	return 0
+}
+
+// Quote character found most often around fields
+func sniffQuote(lines []string) rune {
+	best, bestCount := '"', 0
+	for _, quote := range []rune{'"', '\''} {
+		count := 0
+		for _, line := range lines {
+			runes := []rune(line)
+			for i, r := range runes {
+				if r != quote {
+					continue
+				}
+				// A quote opens a field at the start or after a delimiter,
+				// and closes it before a delimiter or at the end
+				before := i == 0 || isDelimiterCandidate(runes[i-1])
+				after := i == len(runes)-1 || isDelimiterCandidate(runes[i+1])
+				if before || after {
+					count++
+				}
+			}
+		}
+		if count > bestCount {
+			best, bestCount = quote, count
+		}
+	}
+	return best
+}
+
+// Is the character one of the delimiters that can be detected
+func isDelimiterCandidate(r rune) bool {
+	return r == ',' || r == ';' || r == '\t' || r == '|'
+}
+
+// Delimiter found the same number of times on the most lines
+func sniffDelimiter(lines []string, quote rune) rune {
+	best, bestScore := ',', 0
+	for _, comma := range []rune{',', ';', '\t', '|'} {
+		// Count the delimiters outside quotes on each line
+		counts := make(map[int]int)
+		for _, line := range lines {
+			n, quoted := 0, false
+			for _, r := range line {
+				switch {
+				case quote != 0 && r == quote:
+					quoted = !quoted
+				case r == comma && !quoted:
+					n++
+				}
+			}
+			if n > 0 {
+				counts[n]++
+			}
+		}
+		// Lines with the most common count, weighted by the number of columns
+		for n, lines := range counts {
+			if score := lines*1000 + n; score > bestScore {
+				best, bestScore = comma, score
+			}
+		}
+	}
+	return best
+}
+
+// dialectReader reads the records of a CSV file written in any dialect
+// Like csv.Reader with LazyQuotes, a quote inside an unquoted field is kept as is.
+type dialectReader struct {
+	r      *bufio.Reader
+	d      CSVDialect
+	offset int64 // Bytes read so far
+}
+
+// Create new dialectReader
+func newDialectReader(r io.Reader, d CSVDialect) *dialectReader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &dialectReader{r: br, d: d}
+}
+
+// Byte offset of the next record
+func (r *dialectReader) InputOffset() int64 {
+	return r.offset
+}
+
+// Read every remaining record
+func (r *dialectReader) ReadAll() ([][]string, error) {
+	var records [][]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// Read the next record, skipping blank and comment lines
+func (r *dialectReader) Read() ([]string, error) {
+	for {
+		record, err := r.readLine()
+		if err != nil || record != nil {
+			return record, err
+		}
+	}
+}
+
+// Read the next character
+func (r *dialectReader) next() (rune, error) {
+	c, size, err := r.r.ReadRune()
+	r.offset += int64(size)
+	return c, err
+}
+
+// Is the next character c, consuming it if so
+func (r *dialectReader) skipIf(c rune) bool {
+	next, err := r.r.Peek(1)
+	if err != nil || c >= 0x80 || next[0] != byte(c) {
+		return false
+	}
+	r.r.Discard(1)
+	r.offset++
+	return true
+}
+
+// Read one record, or nil for a blank or comment line
+func (r *dialectReader) readLine() ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	lineStart, fieldStart, quoted := true, true, false
+	for {
+		c, err := r.next()
+		if err == io.EOF {
+			if lineStart {
+				return nil, io.EOF
+			}
+			return append(fields, field.String()), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		// \r\n ends a line like \n
+		if c == '\r' && r.skipIf('\n') {
+			c = '\n'
+		}
+		switch {
+		case quoted:
+			switch {
+			case c != r.d.Quote:
+				field.WriteRune(c)
+			case r.skipIf(r.d.Quote):
+				// A doubled quote is a quote character
+				field.WriteRune(c)
+			default:
+				quoted = false
+			}
+			continue
+		case lineStart && r.d.Comment != 0 && c == r.d.Comment:
+			for c != '\n' {
+				if c, err = r.next(); err != nil {
+					break
+				}
+			}
+			return nil, nil
+		case c == '\n':
+			if lineStart {
+				return nil, nil
+			}
+			return append(fields, field.String()), nil
+		case c == r.d.Comma:
+			fields = append(fields, field.String())
+			field.Reset()
+			lineStart, fieldStart = false, true
+			continue
+		case fieldStart && r.d.Quote != 0 && c == r.d.Quote:
+			quoted = true
+		default:
+			field.WriteRune(c)
+		}
+		lineStart, fieldStart = false, false
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDialectReader(t *testing.T) {
+	comma := CSVDialect{Comma: ',', Quote: '"'}
+	tests := []struct {
+		name  string
+		input string
+		d     CSVDialect
+		want  [][]string
+	}{
+		{"plain", "a,b,c\nd,e,f\n", comma, [][]string{{"a", "b", "c"}, {"d", "e", "f"}}},
+		{"no final newline", "a,b\nc,d", comma, [][]string{{"a", "b"}, {"c", "d"}}},
+		{"crlf", "a,b\r\nc,d\r\n", comma, [][]string{{"a", "b"}, {"c", "d"}}},
+		{"lone cr kept", "a\rb,c\n", comma, [][]string{{"a\rb", "c"}}},
+		{"blank lines skipped", "a\n\n\r\nb\n", comma, [][]string{{"a"}, {"b"}}},
+		{"empty fields", ",a,\n", comma, [][]string{{"", "a", ""}}},
+		{"quoted delimiter", `"a,b",c` + "\n", comma, [][]string{{"a,b", "c"}}},
+		{"doubled quote", `"say ""hi""",x` + "\n", comma, [][]string{{`say "hi"`, "x"}}},
+		{"quoted newline", "\"one\r\ntwo\",x\n", comma, [][]string{{"one\ntwo", "x"}}},
+		{"quote inside unquoted field", `5" disk,x` + "\n", comma, [][]string{{`5" disk`, "x"}}},
+		{"single quotes", "'a;b';c\n", CSVDialect{Comma: ';', Quote: '\''}, [][]string{{"a;b", "c"}}},
+		{"no quote", `"a",b` + "\n", CSVDialect{Comma: ','}, [][]string{{`"a"`, "b"}}},
+		{"tabs", "a\tb\n", tabDialect, [][]string{{"a", "b"}}},
+		{"comments", "# note\na,b\n#x,y\n", CSVDialect{Comma: ',', Quote: '"', Comment: '#'}, [][]string{{"a", "b"}}},
+		{"comments kept when off", "#x,y\n", comma, [][]string{{"#x", "y"}}},
+		{"multibyte delimiter", "a；b\n", CSVDialect{Comma: '；', Quote: '"'}, [][]string{{"a", "b"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newDialectReader(strings.NewReader(tt.input), tt.d).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDialectReaderInputOffset(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []int64 // Offset after each record
+	}{
+		{"lf", "a,b\ncd,e\n", []int64{4, 9}},
+		{"crlf", "a,b\r\ncd,e\r\n", []int64{5, 11}},
+		{"blank lines", "a\n\n\nb", []int64{2, 5}},
+		{"quoted newline", "\"x\ny\",z\nw\n", []int64{8, 10}},
+		{"multibyte", "é,ü\nx\n", []int64{6, 8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newDialectReader(strings.NewReader(tt.input), CSVDialect{Comma: ',', Quote: '"'})
+			var got []int64
+			for {
+				if _, err := r.Read(); err != nil {
+					break
+				}
+				got = append(got, r.InputOffset())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSniffDialect(t *testing.T) {
+	auto := CSVDialect{Comma: DialectAuto, Quote: DialectAuto, Comment: DialectAuto}
+	tests := []struct {
+		name   string
+		sample string
+		want   CSVDialect
+	}{
+		{"commas", "a,b,c\nd,e,f\n", CSVDialect{Comma: ',', Quote: '"'}},
+		{"semicolons", "a;b;c\nd;e,1;f\n", CSVDialect{Comma: ';', Quote: '"'}},
+		{"tabs", "a\tb\nc\td\n", CSVDialect{Comma: '\t', Quote: '"'}},
+		{"single quotes", "'a';'b'\n'c';'d'\n", CSVDialect{Comma: ';', Quote: '\''}},
+		{"comments", "# export\na,b\nc,d\n", CSVDialect{Comma: ',', Quote: '"', Comment: '#'}},
+		{"cut last line", "a|b\nc|d\ne,f,g,h", CSVDialect{Comma: '|', Quote: '"'}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SniffDialect(tt.sample, auto); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
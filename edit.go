@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/text/transform"
+)
+
+// Error returned when a streamed table is edited
+var errStreamedEdit = fmt.Errorf("large tables read from the file as needed cannot be edited")
+
+// Check that the table is held in TableData and the cell exists
+func (p *FileProcessor) checkEdit(r, c int) error {
+	if p.Streamed() {
+		return errStreamedEdit
+	}
+	if r < 0 || r >= len(p.TableData) || c < 0 || c >= p.NumCols() {
+		return fmt.Errorf("no cell at %s%d", ColumnName(c), r+1)
+	}
+	return nil
+}
+
+// Change the text of a cell
+func (p *FileProcessor) SetCell(r, c int, value string) error {
+	if err := p.checkEdit(r, c); err != nil {
+		return err
+	}
+	if p.TableData[r][c] != value {
+		p.TableData[r][c], p.Edited = value, true
+	}
+	return nil
+}
+
+// Insert an empty row before row r, or at the end when r is the number of rows
+// The row joins the sheet of the row above it.
+func (p *FileProcessor) InsertRow(r int) error {
+	if p.Streamed() {
+		return errStreamedEdit
+	}
+	if r < 0 || r > len(p.TableData) {
+		return fmt.Errorf("no row %d", r+1)
+	}
+	p.TableData = slices.Insert(p.TableData, r, make([]string, p.NumCols()))
+	// Sheets starting at r now start one row later, the first sheet always at 0
+	for i := range p.SheetRanges {
+		if p.SheetRanges[i].Start > r || (i > 0 && p.SheetRanges[i].Start == r) {
+			p.SheetRanges[i].Start++
+		}
+	}
+	p.Edited = true
+	return nil
+}
+
+// Delete row r
+func (p *FileProcessor) DeleteRow(r int) error {
+	if err := p.checkEdit(r, 0); err != nil {
+		return err
+	}
+	p.TableData = slices.Delete(p.TableData, r, r+1)
+	for i := range p.SheetRanges {
+		if p.SheetRanges[i].Start > r {
+			p.SheetRanges[i].Start--
+		}
+	}
+	p.Edited = true
+	return nil
+}
+
+// Insert an empty column before column c, or at the end when c is the number of columns
+// A new column is ignored until a role is set for it, when roles are mapped.
+func (p *FileProcessor) InsertColumn(c int) error {
+	if p.Streamed() {
+		return errStreamedEdit
+	}
+	if len(p.TableData) == 0 || c < 0 || c > p.NumCols() {
+		return fmt.Errorf("no column %s", ColumnName(c))
+	}
+	for r := range p.TableData {
+		p.TableData[r] = slices.Insert(p.TableData[r], c, "")
+	}
+	if c <= len(p.ColumnRoles) {
+		p.ColumnRoles = slices.Insert(p.ColumnRoles, c, RoleIgnore)
+	}
+	p.Edited = true
+	return nil
+}
+
+// Delete column c
+func (p *FileProcessor) DeleteColumn(c int) error {
+	if err := p.checkEdit(0, c); err != nil {
+		return err
+	}
+	if p.NumCols() == 1 {
+		return fmt.Errorf("cannot delete the only column")
+	}
+	for r := range p.TableData {
+		p.TableData[r] = slices.Delete(p.TableData[r], c, c+1)
+	}
+	if c < len(p.ColumnRoles) {
+		p.ColumnRoles = slices.Delete(p.ColumnRoles, c, c+1)
+	}
+	p.Edited = true
+	return nil
+}
+
+// Save the table to a CSV or XLSX file, which becomes the table file
+// The column roles are saved for the new file.
+func (p *FileProcessor) SaveTable(filePath string) error {
+	if p.Streamed() {
+		return errStreamedEdit
+	}
+	var err error
+	switch ext := strings.ToLower(filepath.Ext(filePath)); ext {
+	case ".csv":
+		err = p.saveCSV(filePath)
+	case ".xlsx":
+		err = p.saveXLSX(filePath)
+	default:
+		err = fmt.Errorf("cannot save as %s, use .csv or .xlsx", ext)
+	}
+	if err != nil {
+		return err
+	}
+	p.TableFilePath, p.Edited = filePath, false
+	return p.SaveMapping()
+}
+
+// Write the table as CSV, with the character set and delimiter it was read with
+func (p *FileProcessor) saveCSV(filePath string) error {
+	if len(p.SheetRanges) > 1 {
+		return fmt.Errorf("a table read from several sheets can only be saved as .xlsx")
+	}
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	var out io.Writer = file
+	if enc := encodings[p.CSVEncoding]; enc != nil {
+		out = transform.NewWriter(file, enc.NewEncoder())
+	}
+	// Tables not read from a CSV file are written with commas and double quotes
+	d := CSVDialect{Comma: ',', Quote: '"'}
+	if p.CSVDialect.Comma > 0 {
+		d = p.CSVDialect
+	}
+	w := bufio.NewWriter(out)
+	for _, row := range p.TableData {
+		writeCSVRow(w, row, d)
+	}
+	err = w.Flush()
+	// Write out the last characters held by the encoder
+	if tw, ok := out.(*transform.Writer); ok && err == nil {
+		err = tw.Close()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Write one record with the delimiter and quote of the dialect
+// A field holding the delimiter, a quote or a line break is quoted, with
+// double quotes if the dialect has no quote character.
+func writeCSVRow(w *bufio.Writer, row []string, d CSVDialect) {
+	quote := d.Quote
+	if quote <= 0 {
+		quote = '"'
+	}
+	for i, field := range row {
+		if i > 0 {
+			w.WriteRune(d.Comma)
+		}
+		if field == "" || !strings.ContainsAny(field, string([]rune{d.Comma, quote, '\r', '\n'})) &&
+			field[0] != ' ' && (d.Comment <= 0 || i > 0 || !strings.HasPrefix(field, string(d.Comment))) {
+			w.WriteString(field)
+			continue
+		}
+		q := string(quote)
+		w.WriteString(q + strings.ReplaceAll(field, q, q+q) + q)
+	}
+	w.WriteString("\n")
+}
+
+// Write the table as a workbook, with one worksheet for each loaded sheet
+func (p *FileProcessor) saveXLSX(filePath string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+	for i, part := range p.TableParts() {
+		name := part.Name
+		if name == "" {
+			name = "Sheet1"
+		}
+		if i == 0 {
+			if err := f.SetSheetName("Sheet1", name); err != nil {
+				return err
+			}
+		} else if _, err := f.NewSheet(name); err != nil {
+			return err
+		}
+		for r := part.Start; r < part.End; r++ {
+			cell, err := excelize.CoordinatesToCellName(1, r-part.Start+1)
+			if err != nil {
+				return err
+			}
+			if err := f.SetSheetRow(name, cell, &p.TableData[r]); err != nil {
+				return err
+			}
+		}
+	}
+	return f.SaveAs(filePath)
+}
+
+// Copy of the processor that can be read while the table is edited
+// The rows of a streamed table are never edited, they stay shared.
+func (p *FileProcessor) Snapshot() *FileProcessor {
+	snapshot := *p
+	snapshot.TableData = make([][]string, len(p.TableData))
+	for r, row := range p.TableData {
+		snapshot.TableData[r] = slices.Clone(row)
+	}
+	snapshot.ColumnRoles = slices.Clone(p.ColumnRoles)
+	snapshot.SheetRanges = slices.Clone(p.SheetRanges)
+	snapshot.Sheets = slices.Clone(p.Sheets)
+	return &snapshot
+}
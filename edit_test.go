@@ -0,0 +1,52 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestEdited(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(p *FileProcessor) error
+		want bool
+	}{
+		{"nothing", func(*FileProcessor) error { return nil }, false},
+		{"same text", func(p *FileProcessor) error { return p.SetCell(0, 0, "A") }, false},
+		{"new text", func(p *FileProcessor) error { return p.SetCell(0, 0, "X") }, true},
+		{"add row", func(p *FileProcessor) error { return p.InsertRow(1) }, true},
+		{"delete row", func(p *FileProcessor) error { return p.DeleteRow(0) }, true},
+		{"add column", func(p *FileProcessor) error { return p.InsertColumn(0) }, true},
+		{"delete column", func(p *FileProcessor) error { return p.DeleteColumn(1) }, true},
+		{"failed edit", func(p *FileProcessor) error { p.DeleteRow(9); return nil }, false},
+		{"pasted again", func(p *FileProcessor) error {
+			p.SetCell(0, 0, "X")
+			return p.LoadText("C\tD")
+		}, false},
+		{"saved", func(p *FileProcessor) error {
+			p.SetCell(0, 0, "X")
+			return p.SaveTable(filepath.Join(p.DestPath, "table.csv"))
+		}, false},
+		{"cleared", func(p *FileProcessor) error {
+			p.SetCell(0, 0, "X")
+			p.Clear()
+			return nil
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewFileProcessor()
+			p.MappingsPath = ""
+			p.DestPath = t.TempDir()
+			if err := p.LoadText("A\tB\n1\t2"); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.edit(p); err != nil {
+				t.Fatal(err)
+			}
+			if p.Edited != tt.want {
+				t.Errorf("Edited = %v, want %v", p.Edited, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	xunicode "golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Character sets of CSV files, in the order shown in the UI
+var EncodingNames = []string{"Auto", "UTF-8", "UTF-16LE", "UTF-16BE", "Windows-1252", "GBK", "Big5", "Shift-JIS", "EUC-KR"}
+
+// Decoders of the character sets, nil when the text is already UTF-8
+var encodings = map[string]encoding.Encoding{
+	"UTF-8":        nil,
+	"UTF-16LE":     xunicode.UTF16(xunicode.LittleEndian, xunicode.IgnoreBOM),
+	"UTF-16BE":     xunicode.UTF16(xunicode.BigEndian, xunicode.IgnoreBOM),
+	"Windows-1252": charmap.Windows1252,
+	"GBK":          simplifiedchinese.GB18030, // Also reads GB2312 and GBK
+	"Big5":         traditionalchinese.Big5,
+	"Shift-JIS":    japanese.ShiftJIS,
+	"EUC-KR":       korean.EUCKR,
+}
+
+// Legacy character sets tried when a file is not UTF-8, preferred first on a tie
+var legacyEncodings = []string{"GBK", "Shift-JIS", "Big5", "EUC-KR", "Windows-1252"}
+
+// Frequent simplified Chinese characters, to tell GBK text from other text read as GBK
+var commonHanzi = func() map[rune]bool {
+	chars := make(map[rune]bool)
+	for _, r := range "" +
+		"的一是不了在人有我他这个们中来上大为和国地到以说时要就出会可也你对生能而子那得于着下自之年过发后作里用道行所然家种事成方" +
+		"多经么去法学如都同现当没动面起看定天分还进好小部其些主样理心她本前开但因只从想实日军者意无力它与长把机十民第公此已工使情" +
+		"明性知全三又关点正业外将两高间由问很最重并物手应战向头文体政美相见被利什二等产或新己制身果加西斯月话合回特代内信表化老给" +
+		"世位次度门任常先海通教儿原东声提立及比员解水名真论处走义各入几口认条平系气题活尔更别打女变四神总何电数安少报才结反受目太" +
+		"量再感建务做接必场件计管期市直德资命山金指克许统区保至队形社便空决治展马科司五基眼书非则听白却界达光放强即像难且权思王象" +
+		"完设式色路记南品住告类求据程北边死张该交规万取拉格望觉术领共确传师观清今切院让识候带导争运笑飞风步改收根干造言联持组每济" +
+		"车亲极林服快办议往元英士证近失转夫令准布始怎呢存未远叫台单影具罗字爱击流备兵连调深商算质团集百需价花党华城石级整府离况亚" +
+		"请技际约示复病息究线似官火断精满支视消越器容照须九增研写称企八功吗包片史委乎查轻易早曾除农找装广显吧阿李标谈吃图念六引历" +
+		"首医局突专费号尽另周较注语仅考落青随选列武红响虽推势参希古众构房半节土投某案黑维革划敌致陈律足态护七兴派孩验责营星够章音" +
+		"跟志底站严巴例防族供效续施留讲型料终答紧黄绝奇察母京段依批群项故按河米围江织害斗双境客纪采举杀攻父苏密低朝友诉止细愿千值" +
+		"仍男钱破网热助倒育属坐帝限船脸职速刻乐否刚威毛状率甚独球般普怕弹校苦创假久错承印晚兰试股拿脑预谁益阳若哪微尼继送急血惊伤" +
+		"素药适波夜省初喜卫源食险待述陆习置居劳财环排福纳欢雷警获模充负云停木游龙树疑层冷洲冲射略范竟句室异激汉村哈策演简卡罪判担" +
+		"州静退既衣您宗积余痛检差富灵协角占配征修皮挥胜降阶审沉坚善妈刘读啊超免压银买皇养伊怀执副乱抗犯追帮宣佛岁航优怪香著田铁控" +
+		"税左右份穿艺背阵草脚概恶块顿敢守酒岛托央户烈洋哥索胡款靠评版宝座释景顾弟登货互付伯慢欧换闻危忙核暗姐介坏讨丽良序升监临亮" +
+		"露永呼味野架域沙掉括舰鱼杂误湾吉减编楚肯测败屋跑梦散温困剑渐封救贵枪缺楼县尚毫移娘朋画班智亦耳恩短掌恐遗固席松秘谢鲁遇康" +
+		"虑幸均销钟诗藏赶剧票损忽巨炮旧端探湖录叶春乡附吸予礼港雨呀板庭妇归睛饭额含顺输摇招婚脱补谓督毒油疗旅泽材灭逐莫笔亡鲜词圣" +
+		"择寻厂睡博勒烟授诺伦岸奥唐卖俄炸载洛健堂旁宫喝借君禁阴园谋宋避抓荣姑孙逃牙束跳顶玉镇雪午练迫爷篇肉嘴馆遍凡础洞卷坦牛宁纸" +
+		"诸训私庄祖丝翻暴森塔默握戏隐熟骨访弱蒙歌店鬼软典欲萨伙遭盘爸扩盖弄雄稳忘亿刺拥徒姆杨齐赛趣曲刀床迎冰虚玩析窗醒妻透购替塞" +
+		"努休虎扬途侵刑绿兄迅套贸毕唯谷轮库迹尤竞街促延震弃甲伟麻川申缓潜闪售灯针哲络抵朱埃抱鼓植纯夏忍页杰筑折郑贝尊吴秀混臣雅振" +
+		"染盛怒舞圆搞狂措姓残秋培迷诚宽宇猛摆梅毁伸摩盟末乃悲拍丁赵邮蓝逻扫帐幅龄竹纷弗辑骑扎窝钢咱漠尺糊葛鉴拖瑞聊捷隆仪冒掘茶栏" +
+		"厅晓夹档账季频稿" {
+		chars[r] = true
+	}
+	return chars
+}()
+
+// Bytes read to guess the character set
+const encodingSampleSize = 64 << 10
+
+// Get the character set from its name, ignoring case; "" is Auto
+func ParseEncoding(name string) (string, error) {
+	if name == "" {
+		return "Auto", nil
+	}
+	for _, n := range EncodingNames {
+		if strings.EqualFold(n, name) {
+			return n, nil
+		}
+	}
+	return "Auto", fmt.Errorf("unknown encoding: %s", name)
+}
+
+// Open a CSV file as UTF-8 text
+// Returns the text reader, the character set used and the length of the byte order mark
+func (p *FileProcessor) openCSVText(filePath string) (io.Reader, *os.File, string, int64, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, "", 0, err
+	}
+	sample := make([]byte, encodingSampleSize)
+	n, err := io.ReadFull(file, sample)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		file.Close()
+		return nil, nil, "", 0, err
+	}
+	name, bom := DetectEncoding(sample[:n])
+	if p.Encoding != "" && p.Encoding != "Auto" {
+		name = p.Encoding
+	}
+	if _, err := file.Seek(int64(bom), io.SeekStart); err != nil {
+		file.Close()
+		return nil, nil, "", 0, err
+	}
+	if enc := encodings[name]; enc != nil {
+		return transform.NewReader(file, enc.NewDecoder()), file, name, int64(bom), nil
+	}
+	return file, file, name, int64(bom), nil
+}
+
+// Guess the character set of the text and the length of its byte order mark
+func DetectEncoding(sample []byte) (string, int) {
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}):
+		return "UTF-8", 3
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		return "UTF-16LE", 2
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		return "UTF-16BE", 2
+	}
+	// UTF-16 without a mark: ASCII text has a zero in every other byte
+	if len(sample) >= 4 {
+		even, odd := 0, 0
+		for i := 0; i+1 < len(sample); i += 2 {
+			if sample[i] == 0 {
+				even++
+			}
+			if sample[i+1] == 0 {
+				odd++
+			}
+		}
+		pairs := len(sample) / 2
+		switch {
+		case odd*3 > pairs && even*10 < pairs:
+			return "UTF-16LE", 0
+		case even*3 > pairs && odd*10 < pairs:
+			return "UTF-16BE", 0
+		}
+	}
+	if validUTF8Prefix(sample) {
+		return "UTF-8", 0
+	}
+	// Pick the legacy character set the text reads best in, ignoring a cut last line
+	if i := bytes.LastIndexByte(sample, '\n'); i > 0 {
+		sample = sample[:i+1]
+	}
+	best, bestScore := "Windows-1252", 0
+	for i, name := range legacyEncodings {
+		text, err := encodings[name].NewDecoder().Bytes(sample)
+		if err != nil {
+			continue
+		}
+		if score := textScore(name, []rune(string(text))); i == 0 || score > bestScore {
+			best, bestScore = name, score
+		}
+	}
+	return best, 0
+}
+
+// Is the sample valid UTF-8, allowing a character cut at the end
+func validUTF8Prefix(sample []byte) bool {
+	for cut := 0; cut < utf8.UTFMax && cut <= len(sample); cut++ {
+		if utf8.Valid(sample[:len(sample)-cut]) {
+			return true
+		}
+	}
+	return false
+}
+
+// How plausible the decoded text is for the character set
+// Letters of the expected script count for, broken characters against.
+func textScore(name string, text []rune) int {
+	gbk := simplifiedchinese.GBK.NewEncoder()
+	score := 0
+	for i, r := range text {
+		switch {
+		case r < utf8.RuneSelf:
+		case r == utf8.RuneError || unicode.Is(unicode.Co, r) || unicode.IsControl(r):
+			score -= 10
+		case i > 0 && i+1 < len(text) && isASCIILetterRune(text[i-1]) && isASCIILetterRune(text[i+1]):
+			// A single character inside a Latin word is a misread byte pair
+			if name == "Windows-1252" {
+				score++
+			} else {
+				score--
+			}
+		case r >= 0xFF61 && r <= 0xFF9F, r >= 0x3400 && r <= 0x4DBF, r >= 0xF900 && r <= 0xFAFF:
+			// Half-width katakana and rare ideographs mostly come from misreading
+			score -= 2
+		case name == "Windows-1252":
+			if unicode.IsLetter(r) && r <= 0xFF {
+				score++
+			}
+		case name == "GBK" && unicode.Is(unicode.Han, r):
+			// Characters outside GB2312 are rare in simplified Chinese
+			if commonHanzi[r] {
+				score += 3
+			} else if b, err := gbk.Bytes([]byte(string(r))); err == nil && len(b) == 2 && b[0] >= 0xA1 && b[1] >= 0xA1 {
+				score++
+			}
+		case name == "Big5" && unicode.Is(unicode.Han, r):
+			score += 2
+		case name == "Shift-JIS" && unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			score += 3
+		case name == "Shift-JIS" && unicode.Is(unicode.Han, r):
+			score++
+		case name == "EUC-KR" && unicode.Is(unicode.Hangul, r):
+			score += 3
+		}
+	}
+	return score
+}
+
+// Is the character a letter from A to Z
+func isASCIILetterRune(r rune) bool {
+	return r < utf8.RuneSelf && isASCIILetter(byte(r))
+}
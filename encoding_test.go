@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+// Encode the text in the named character set for a test
+func encodeText(t *testing.T, name, text string) []byte {
+	t.Helper()
+	b, err := encodings[name].NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		t.Fatalf("encode %s: %v", name, err)
+	}
+	return b
+}
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		sample  func(t *testing.T) []byte
+		want    string
+		wantBOM int
+	}{
+		{"empty", func(*testing.T) []byte { return nil }, "UTF-8", 0},
+		{"ascii", func(*testing.T) []byte { return []byte("Name,Path\nAlpha,Beta\n") }, "UTF-8", 0},
+		{"utf-8", func(*testing.T) []byte { return []byte("Name\nÜbersicht\n项目\n") }, "UTF-8", 0},
+		{"utf-8 cut inside a character", func(*testing.T) []byte { return []byte("Name\n项目")[:9] }, "UTF-8", 0},
+		{"utf-8 bom", func(*testing.T) []byte { return append([]byte{0xEF, 0xBB, 0xBF}, "a,b\n"...) }, "UTF-8", 3},
+		{"utf-16le bom", func(*testing.T) []byte { return []byte{0xFF, 0xFE, 'a', 0, ',', 0} }, "UTF-16LE", 2},
+		{"utf-16be bom", func(*testing.T) []byte { return []byte{0xFE, 0xFF, 0, 'a', 0, ','} }, "UTF-16BE", 2},
+		{"utf-16le without bom", func(*testing.T) []byte { return []byte("N\x00a\x00m\x00e\x00\n\x00") }, "UTF-16LE", 0},
+		{"utf-16be without bom", func(*testing.T) []byte { return []byte("\x00N\x00a\x00m\x00e\x00\n") }, "UTF-16BE", 0},
+		{"gbk", func(t *testing.T) []byte { return encodeText(t, "GBK", "项目名称,负责人\n市场部,张三\n") }, "GBK", 0},
+		{"big5", func(t *testing.T) []byte { return encodeText(t, "Big5", "專案名稱,負責人\n資料夾,說明\n") }, "Big5", 0},
+		{"shift-jis", func(t *testing.T) []byte {
+			return encodeText(t, "Shift-JIS", "プロジェクト,フォルダ\nデータ,メモ\n")
+		}, "Shift-JIS", 0},
+		{"euc-kr", func(t *testing.T) []byte { return encodeText(t, "EUC-KR", "프로젝트,폴더\n데이터,메모\n") }, "EUC-KR", 0},
+		{"windows-1252", func(t *testing.T) []byte { return encodeText(t, "Windows-1252", "Café,Straße\nNaïve,Übersicht\n") }, "Windows-1252", 0},
+		{"cut last line", func(t *testing.T) []byte {
+			return append(encodeText(t, "Windows-1252", "Résumé,Façade\n"), 0xE9, 0xA1)
+		}, "Windows-1252", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, bom := DetectEncoding(tt.sample(t))
+			if got != tt.want || bom != tt.wantBOM {
+				t.Errorf("got %s with a %d byte mark, want %s with %d", got, bom, tt.want, tt.wantBOM)
+			}
+		})
+	}
+}
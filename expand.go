@@ -0,0 +1,151 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Most names one cell, and paths one row, may expand to unless set otherwise
+const DefaultExpandLimit = 1000
+
+// Ranges inside braces: {1..9}, {01..52}, {1..20..5}, {A..F}
+var (
+	numberRange = regexp.MustCompile(`^(-?\d+)\.\.(-?\d+)(?:\.\.(-?\d+))?$`)
+	letterRange = regexp.MustCompile(`^([a-zA-Z])\.\.([a-zA-Z])(?:\.\.(-?\d+))?$`)
+)
+
+// Expand shell-style braces in a name: "Q{1..4}" gives Q1, Q2, Q3 and Q4,
+// "{Docs,Data}" gives Docs and Data. Braces without a list or range are kept.
+// At most limit names are returned; capped tells if more were dropped.
+func ExpandBraces(name string, limit int) (names []string, capped bool) {
+	e := &braceExpander{limit: limit}
+	for _, n := range e.expand(name) {
+		if n != "" {
+			names = append(names, n)
+		}
+	}
+	if len(names) == 0 {
+		names = []string{name}
+	}
+	return names, e.capped
+}
+
+// braceExpander expands names, stopping once it has more than limit of them
+type braceExpander struct {
+	limit  int
+	capped bool
+}
+
+// Expand the first brace group of s, then the rest of s
+func (e *braceExpander) expand(s string) []string {
+	for i := 0; i < len(s); i++ {
+		if s[i] != '{' {
+			continue
+		}
+		end, items, ok := e.group(s, i)
+		if !ok {
+			continue
+		}
+		prefix := s[:i]
+		suffixes := e.expand(s[end+1:])
+		var out []string
+		for _, item := range items {
+			for _, middle := range e.expand(item) {
+				for _, suffix := range suffixes {
+					if len(out) == e.limit {
+						e.capped = true
+						return out
+					}
+					out = append(out, prefix+middle+suffix)
+				}
+			}
+		}
+		return out
+	}
+	return []string{s}
+}
+
+// Read the brace group opening at s[open]
+// Returns the index of its closing brace and its items, not yet expanded.
+func (e *braceExpander) group(s string, open int) (int, []string, bool) {
+	depth, start := 0, open+1
+	var items []string
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case ',':
+			if depth == 1 {
+				items = append(items, s[start:i])
+				start = i + 1
+			}
+		case '}':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			if items != nil {
+				return i, append(items, s[start:i]), true
+			}
+			items, ok := e.sequence(s[open+1 : i])
+			return i, items, ok
+		}
+	}
+	return 0, nil, false
+}
+
+// Items of a range such as 1..9, 01..52 or A..F
+func (e *braceExpander) sequence(body string) ([]string, bool) {
+	if m := numberRange.FindStringSubmatch(body); m != nil {
+		from, err1 := strconv.Atoi(m[1])
+		to, err2 := strconv.Atoi(m[2])
+		if err1 != nil || err2 != nil {
+			return nil, false
+		}
+		// Zero padded ends give zero padded numbers
+		width := 0
+		if padded(m[1]) || padded(m[2]) {
+			width = max(len(m[1]), len(m[2]))
+		}
+		return e.steps(from, to, m[3], func(n int) string {
+			s := strconv.Itoa(n)
+			if n < 0 {
+				return "-" + pad(width-1, s[1:])
+			}
+			return pad(width, s)
+		}), true
+	}
+	if m := letterRange.FindStringSubmatch(body); m != nil {
+		return e.steps(int(m[1][0]), int(m[2][0]), m[3], func(n int) string {
+			return string(rune(n))
+		}), true
+	}
+	return nil, false
+}
+
+// Values from from to to, in either direction, by the step if given
+func (e *braceExpander) steps(from, to int, step string, format func(int) string) []string {
+	by := 1
+	if n, err := strconv.Atoi(step); err == nil && n != 0 {
+		by = max(n, -n)
+	}
+	if from > to {
+		by = -by
+	}
+	var items []string
+	for n := from; (by > 0 && n <= to) || (by < 0 && n >= to); n += by {
+		if len(items) == e.limit {
+			e.capped = true
+			break
+		}
+		items = append(items, format(n))
+	}
+	return items
+}
+
+// Does the number start with a zero that is not its only digit
+func padded(number string) bool {
+	number = strings.TrimPrefix(number, "-")
+	return len(number) > 1 && number[0] == '0'
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandBraces(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		limit  int
+		want   []string
+		capped bool
+	}{
+		{"no braces", "Plans", 10, []string{"Plans"}, false},
+		{"list", "{Docs,Data}", 10, []string{"Docs", "Data"}, false},
+		{"prefix and suffix", "Q{1..4} Notes", 10, []string{"Q1 Notes", "Q2 Notes", "Q3 Notes", "Q4 Notes"}, false},
+		{"zero padding", "W{08..11}", 10, []string{"W08", "W09", "W10", "W11"}, false},
+		{"padding from either end", "{1..010..3}", 10, []string{"001", "004", "007", "010"}, false},
+		{"descending", "{3..1}", 10, []string{"3", "2", "1"}, false},
+		{"negative", "{-1..1}", 10, []string{"-1", "0", "1"}, false},
+		{"padded negative", "{-01..01}", 10, []string{"-01", "000", "001"}, false},
+		{"step", "{0..20..5}", 10, []string{"0", "5", "10", "15", "20"}, false},
+		{"negative step", "{10..0..-5}", 10, []string{"10", "5", "0"}, false},
+		{"letters", "Room {a..c}", 10, []string{"Room a", "Room b", "Room c"}, false},
+		{"letter step", "{A..E..2}", 10, []string{"A", "C", "E"}, false},
+		{"two groups", "{A,B}{1..2}", 10, []string{"A1", "A2", "B1", "B2"}, false},
+		{"nested", "{a,b{1..2},c}", 10, []string{"a", "b1", "b2", "c"}, false},
+		{"nested list", "x{y,{p,q}z}", 10, []string{"xy", "xpz", "xqz"}, false},
+		{"literal word", "Report {draft}", 10, []string{"Report {draft}"}, false},
+		{"literal empty", "a{}b", 10, []string{"a{}b"}, false},
+		{"unclosed", "a{1..3", 10, []string{"a{1..3"}, false},
+		{"literal then group", "{x}{1..2}", 10, []string{"{x}1", "{x}2"}, false},
+		{"empty items dropped", "{,A,}", 10, []string{"A"}, false},
+		{"only empty items", "{,}", 10, []string{"{,}"}, false},
+		{"limit on range", "{1..100}", 3, []string{"1", "2", "3"}, true},
+		{"limit on product", "{a,b}{1..5}", 4, []string{"a1", "a2", "a3", "a4"}, true},
+		{"limit on huge range", "{1..999999999}", 2, []string{"1", "2"}, true},
+		{"limit reached exactly", "{1..3}", 3, []string{"1", "2", "3"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, capped := ExpandBraces(tt.input, tt.limit)
+			if !reflect.DeepEqual(got, tt.want) || capped != tt.capped {
+				t.Errorf("got %q capped %v, want %q capped %v", got, capped, tt.want, tt.capped)
+			}
+		})
+	}
+}
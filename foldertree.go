@@ -0,0 +1,64 @@
+package main
+
+import (
+	"path/filepath"
+)
+
+// FolderNode is a folder or file of a plan in the tree under the target path
+type FolderNode struct {
+	Name     string
+	Kind     OpKind
+	File     bool
+	Reason   string
+	Row      int      // Row that first lists the folder, -1 for the target path
+	Children []string // IDs of the folders inside it, in plan order
+}
+
+// FolderTree is the folder structure a plan leads to under its target path
+// Nodes are keyed by their path relative to the target path, "." being the
+// target path itself and "" the root holding it, as widget.Tree expects.
+type FolderTree struct {
+	Nodes map[string]*FolderNode
+}
+
+// Arrange the folders of the plan as a tree
+func NewFolderTree(plan *Plan) *FolderTree {
+	t := &FolderTree{Nodes: map[string]*FolderNode{
+		"":  {Row: -1, Children: []string{"."}},
+		".": {Name: plan.DestPath, Kind: OpExists, Row: -1},
+	}}
+	for _, op := range plan.Operations {
+		if op.Path == "" {
+			continue
+		}
+		id := plan.RelPath(op)
+		if _, ok := t.Nodes[id]; ok {
+			// Listed again by a later row
+			continue
+		}
+		// A name with a path separator hangs under the nearest planned folder
+		parent := filepath.Dir(id)
+		for t.Nodes[parent] == nil && parent != filepath.Dir(parent) {
+			parent = filepath.Dir(parent)
+		}
+		if t.Nodes[parent] == nil {
+			parent = "."
+		}
+		t.Nodes[id] = &FolderNode{Name: op.Name, Kind: op.Kind, File: op.File, Reason: op.Reason, Row: op.Row}
+		t.Nodes[parent].Children = append(t.Nodes[parent].Children, id)
+	}
+	return t
+}
+
+// IDs of the folders inside the node
+func (t *FolderTree) ChildIDs(id string) []string {
+	if node := t.Nodes[id]; node != nil {
+		return node.Children
+	}
+	return nil
+}
+
+// Does the node have folders inside it
+func (t *FolderTree) IsBranch(id string) bool {
+	return len(t.ChildIDs(id)) > 0
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Journal records the folders created by one run so it can be undone
+type Journal struct {
+	Time         time.Time `json:"time"`
+	TablePath    string    `json:"table_path"`
+	DestPath     string    `json:"dest_path"`
+	Created      []string  `json:"created"`                // Folders and files in creation order, parents first
+	Copied       []string  `json:"copied,omitempty"`       // Files and folders copied from the template, parents first
+	Placeholders []string  `json:"placeholders,omitempty"` // Placeholder files put into empty folders
+}
+
+// UndoResult lists what happened to each folder of the journal
+type UndoResult struct {
+	Removed []string
+	Kept    []string // Not empty any more, left in place
+	Missing []string // Already gone
+	Errors  []error
+}
+
+// Folder used to store the application's own files
+func ConfigDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "Folder-Creator"), nil
+}
+
+// Default location of the journal of the last run
+func DefaultJournalPath() string {
+	dir, err := ConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "last-run.json")
+}
+
+// Build the journal of a run
+func NewJournal(tablePath, destPath string, result *RunResult) *Journal {
+	j := &Journal{
+		Time:         time.Now(),
+		TablePath:    tablePath,
+		DestPath:     destPath,
+		Created:      []string{},
+		Copied:       result.Copied,
+		Placeholders: result.Placeholders,
+	}
+	for _, res := range result.Results {
+		if res.Status == ResultCreated {
+			j.Created = append(j.Created, res.Path)
+		}
+	}
+	return j
+}
+
+// Did the run add nothing that undo could remove
+func (j *Journal) Empty() bool {
+	return len(j.Created) == 0 && len(j.Copied) == 0 && len(j.Placeholders) == 0
+}
+
+// Load a journal file
+func LoadJournal(filePath string) (*Journal, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("there is no run to undo")
+		}
+		return nil, err
+	}
+	j := &Journal{}
+	if err := json.Unmarshal(data, j); err != nil {
+		return nil, fmt.Errorf("damaged journal %s: %v", filePath, err)
+	}
+	return j, nil
+}
+
+// Write the journal file, creating its folder if needed
+func (j *Journal) Save(filePath string) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, data, 0644)
+}
+
+// Remove the placeholders, the template files and the folders of the journal, deepest first,
+// keeping any folder that is not empty and any file changed since the run
+func (j *Journal) Undo() *UndoResult {
+	result := &UndoResult{}
+	for _, path := range j.Placeholders {
+		result.remove(path, j.Time)
+	}
+	for i := len(j.Copied) - 1; i >= 0; i-- {
+		result.remove(j.Copied[i], j.Time)
+	}
+	for i := len(j.Created) - 1; i >= 0; i-- {
+		result.remove(j.Created[i], j.Time)
+	}
+	return result
+}
+
+// Remove a folder if it is empty or a file if it was not changed after since
+func (r *UndoResult) remove(path string, since time.Time) {
+	info, err := os.Lstat(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		r.Missing = append(r.Missing, path)
+		return
+	case err != nil:
+		r.Kept = append(r.Kept, path)
+		r.Errors = append(r.Errors, err)
+		return
+	case !info.IsDir() && info.ModTime().After(since):
+		r.Kept = append(r.Kept, path)
+		return
+	}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		switch {
+		case err != nil:
+			r.Kept = append(r.Kept, path)
+			r.Errors = append(r.Errors, err)
+			return
+		case len(entries) > 0:
+			r.Kept = append(r.Kept, path)
+			return
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		r.Kept = append(r.Kept, path)
+		r.Errors = append(r.Errors, err)
+		return
+	}
+	r.Removed = append(r.Removed, path)
+}
+
+// Undo the journal file and keep only the folders that could not be removed
+func UndoJournalFile(filePath string) (*UndoResult, error) {
+	j, err := LoadJournal(filePath)
+	if err != nil {
+		return nil, err
+	}
+	result := j.Undo()
+	if len(result.Kept) == 0 {
+		return result, os.Remove(filePath)
+	}
+	// Keep the remaining folders in creation order so undo can be retried
+	kept := make(map[string]bool)
+	for _, path := range result.Kept {
+		kept[path] = true
+	}
+	j.Created, j.Copied = keptPaths(j.Created, kept), keptPaths(j.Copied, kept)
+	j.Placeholders = keptPaths(j.Placeholders, kept)
+	return result, j.Save(filePath)
+}
+
+// Paths of the list that were kept, in the same order
+func keptPaths(paths []string, kept map[string]bool) []string {
+	remaining := []string{}
+	for _, path := range paths {
+		if kept[path] {
+			remaining = append(remaining, path)
+		}
+	}
+	return remaining
+}
+
+// One line summary of the undo
+func (r *UndoResult) Summary() string {
+	return fmt.Sprintf("%d removed, %d kept, %d already gone",
+		len(r.Removed), len(r.Kept), len(r.Missing))
+}
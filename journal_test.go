@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestJournalKeptByEmptyRuns(t *testing.T) {
+	tests := []struct {
+		name string
+		runs [][][]string // Tables run one after another
+		want []string     // Folders in the journal afterwards
+	}{
+		{"one run", [][][]string{{{"A"}, {"B"}}}, []string{"A", "B"}},
+		{"same table again", [][][]string{{{"A"}}, {{"A"}}}, []string{"A"}},
+		{"table with nothing to create", [][][]string{{{"A"}}, {{""}}}, []string{"A"}},
+		{"second run adds a folder", [][][]string{{{"A"}}, {{"A"}, {"B"}}}, []string{"B"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewFileProcessor()
+			p.DestPath = t.TempDir()
+			p.JournalPath = filepath.Join(t.TempDir(), "last-run.json")
+			for _, rows := range tt.runs {
+				p.TableData = rows
+				if _, err := p.GenerateFoldersContext(context.Background(), nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			journal, err := LoadJournal(p.JournalPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, path := range journal.Created {
+				got = append(got, filepath.Base(path))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("journal lists %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"os"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -8,6 +9,10 @@ import (
 )
 
 func main() {
+	// Run in headless mode when a subcommand is given
+	if args := CLIArgs(os.Args[1:]); args != nil {
+		os.Exit(NewCLI().Run(args))
+	}
 	// Create the application
 	MyApp := app.NewWithID("Folder Creator")
 	// Load and Set the custom font file
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ColumnRole tells how the cells of a column are used
+type ColumnRole int
+
+const (
+	RoleTop       ColumnRole = iota // Name of the top-level folder
+	RoleSubfolder                   // Subfolder of the deepest level of the row
+	RoleNested                      // Next level inside the previous one
+	RoleIgnore                      // Not used
+	RoleMetadata                    // Kept with the row but not turned into a folder
+	RoleFiles                       // Files created in the deepest level of the row
+)
+
+// Names of the column roles, in the order shown in the UI
+var ColumnRoleNames = []string{"Top-level name", "Subfolder", "Nested level", "Ignore", "Metadata", "Files"}
+
+// Short names of the column roles for the command line and saved mappings
+var columnRoleKeys = []string{"top", "sub", "nested", "ignore", "meta", "files"}
+
+// Name of the role
+func (r ColumnRole) String() string {
+	if int(r) >= 0 && int(r) < len(ColumnRoleNames) {
+		return ColumnRoleNames[r]
+	}
+	return "Unknown"
+}
+
+// Short name of the role
+func (r ColumnRole) Key() string {
+	if int(r) >= 0 && int(r) < len(columnRoleKeys) {
+		return columnRoleKeys[r]
+	}
+	return "unknown"
+}
+
+// Get the role from its name or short name, ignoring case
+func ParseColumnRole(name string) (ColumnRole, error) {
+	name = strings.TrimSpace(name)
+	for i := range ColumnRoleNames {
+		if strings.EqualFold(ColumnRoleNames[i], name) || strings.EqualFold(columnRoleKeys[i], name) {
+			return ColumnRole(i), nil
+		}
+	}
+	return RoleIgnore, fmt.Errorf("unknown column role: %s", name)
+}
+
+// Does the role turn the cell into a folder
+func (r ColumnRole) IsFolder() bool {
+	return r == RoleTop || r == RoleSubfolder || r == RoleNested
+}
+
+// Does the role turn the cell into a folder or a file
+func (r ColumnRole) IsPath() bool {
+	return r.IsFolder() || r == RoleFiles
+}
+
+// Words that usually appear in a header row
+var headerWords = []string{
+	"name", "folder", "subfolder", "sub-folder", "project", "client", "customer",
+	"year", "phase", "level", "department", "category", "code", "id", "title",
+	"description", "note", "notes", "column", "directory", "path", "type", "status",
+	"file",
+}
+
+// Guess if the first row of the table is a header
+// The first row must look like column titles and the second must not,
+// so a list of names such as "Project Alpha" keeps its first folder.
+func DetectHeader(rows [][]string) bool {
+	if len(rows) < 2 {
+		return false
+	}
+	return looksLikeHeader(rows[0]) && !looksLikeHeader(rows[1])
+}
+
+// Do most filled cells of the row look like column titles such as "Project" or "Subfolder 1"
+// Every word of such a cell, numbers aside, is a header word.
+func looksLikeHeader(row []string) bool {
+	filled, matched := 0, 0
+	for _, cell := range row {
+		cell = strings.ToLower(strings.TrimSpace(cell))
+		if cell == "" {
+			continue
+		}
+		filled++
+		words := strings.FieldsFunc(cell, func(r rune) bool {
+			return r == ' ' || r == '_' || r == '-' || r == '.' || (r >= '0' && r <= '9')
+		})
+		title := len(words) > 0
+		for _, word := range words {
+			title = title && isHeaderWord(word)
+		}
+		if title {
+			matched++
+		}
+	}
+	return filled > 0 && matched*2 > filled
+}
+
+// Is the word one of the header words, singular or plural
+func isHeaderWord(word string) bool {
+	word = strings.TrimSuffix(word, "s")
+	for _, w := range headerWords {
+		if word == w || word == strings.TrimSuffix(w, "s") {
+			return true
+		}
+	}
+	return false
+}
+
+// Default role of each column for a folder mode
+func DefaultRoles(mode FolderMode, cols int) []ColumnRole {
+	roles := make([]ColumnRole, cols)
+	for c := range roles {
+		switch {
+		case c == 0:
+			roles[c] = RoleTop
+		case mode == ModeNested:
+			roles[c] = RoleNested
+		default:
+			roles[c] = RoleSubfolder
+		}
+	}
+	return roles
+}
+
+// Role of every column, from the mapping if set or from the folder mode
+func (p *FileProcessor) EffectiveRoles() []ColumnRole {
+	roles := DefaultRoles(p.Mode, p.NumCols())
+	for c := range roles {
+		if c < len(p.ColumnRoles) {
+			roles[c] = p.ColumnRoles[c]
+		}
+	}
+	return roles
+}
+
+// Column titles when the table has a header row, otherwise nil
+func (p *FileProcessor) Headers() []string {
+	if !p.HasHeader || p.NumRows() == 0 {
+		return nil
+	}
+	return p.Row(0)
+}
+
+// Is the row the header of the table or of one of its sheets
+func (p *FileProcessor) IsHeaderRow(row int) bool {
+	if !p.HasHeader {
+		return false
+	}
+	for _, part := range p.TableParts() {
+		if part.Start == row {
+			return true
+		}
+	}
+	return false
+}
+
+// ColumnMapping is the header and role setting saved for a table file
+type ColumnMapping struct {
+	HasHeader bool     `json:"has_header"`
+	Roles     []string `json:"roles"`
+}
+
+// Default location of the saved column mappings
+func DefaultMappingsPath() string {
+	dir, err := ConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "mappings.json")
+}
+
+// Key of a table file in the mappings file
+func mappingKey(tablePath string) string {
+	if abs, err := filepath.Abs(tablePath); err == nil {
+		return abs
+	}
+	return tablePath
+}
+
+// Read every saved mapping
+func loadMappings(filePath string) (map[string]ColumnMapping, error) {
+	mappings := make(map[string]ColumnMapping)
+	data, err := os.ReadFile(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return mappings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &mappings); err != nil {
+		return nil, fmt.Errorf("damaged mappings file %s: %v", filePath, err)
+	}
+	return mappings, nil
+}
+
+// Apply the mapping saved for the loaded table, or guess the header row
+func (p *FileProcessor) ApplySavedMapping() {
+	p.HasHeader = DetectHeader(p.FirstRows(2))
+	p.ColumnRoles = nil
+	if p.MappingsPath == "" || p.TableFilePath == "" {
+		return
+	}
+	mappings, err := loadMappings(p.MappingsPath)
+	if err != nil {
+		return
+	}
+	mapping, ok := mappings[mappingKey(p.TableFilePath)]
+	if !ok {
+		return
+	}
+	p.HasHeader = mapping.HasHeader
+	for _, name := range mapping.Roles {
+		role, err := ParseColumnRole(name)
+		if err != nil {
+			role = RoleIgnore
+		}
+		p.ColumnRoles = append(p.ColumnRoles, role)
+	}
+}
+
+// Save the header and roles of the loaded table for the next time it is loaded
+func (p *FileProcessor) SaveMapping() error {
+	if p.MappingsPath == "" || p.TableFilePath == "" {
+		return nil
+	}
+	mappings, err := loadMappings(p.MappingsPath)
+	if err != nil {
+		return err
+	}
+	mapping := ColumnMapping{HasHeader: p.HasHeader, Roles: []string{}}
+	for _, role := range p.ColumnRoles {
+		mapping.Roles = append(mapping.Roles, role.Key())
+	}
+	mappings[mappingKey(p.TableFilePath)] = mapping
+	if err := os.MkdirAll(filepath.Dir(p.MappingsPath), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(mappings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p.MappingsPath, data, 0644)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NamePattern renders the top-level folder name of a row with text/template
+// The same syntax renders the text of the files created from a files column.
+type NamePattern struct {
+	kind string // "name pattern" or "file template", used in errors
+	text string
+	tmpl *template.Template
+}
+
+// Parse a name pattern such as {{.Col "Code"}}_{{.Col "Client" | upper}}
+// Dates in the pattern are the time it was parsed, so every row gets the same.
+func ParseNamePattern(text string) (*NamePattern, error) {
+	return parsePattern("name pattern", text)
+}
+
+// Parse the template of the text written into new files
+func ParseFileTemplate(text string) (*NamePattern, error) {
+	return parsePattern("file template", text)
+}
+
+// Parse a pattern of the given kind
+func parsePattern(kind, text string) (*NamePattern, error) {
+	now := time.Now()
+	funcs := template.FuncMap{
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+		"trim":     strings.TrimSpace,
+		"replace":  func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"slug":     slug,
+		"pad":      pad,
+		"truncate": truncate,
+		"date":     func(layout string) string { return now.Format(layout) },
+	}
+	tmpl, err := template.New("name").Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", kind, err)
+	}
+	return &NamePattern{kind: kind, text: text, tmpl: tmpl}, nil
+}
+
+// Text of the pattern
+func (np *NamePattern) String() string {
+	return np.text
+}
+
+// NameData is what a name pattern can use for one row
+type NameData struct {
+	Row     int    // Row number in the table, from 1
+	Counter int    // Number of the folder among those named by the pattern, from 1
+	Folder  string // Name of the folder holding the file, for file templates
+	File    string // Name of the file, for file templates
+	cells   []string
+	headers []string
+}
+
+// Cell of the row by column letter or column title, titles first
+func (d NameData) Col(ref string) (string, error) {
+	for c, title := range d.headers {
+		if strings.EqualFold(strings.TrimSpace(title), ref) && c < len(d.cells) {
+			return strings.TrimSpace(d.cells[c]), nil
+		}
+	}
+	if c, ok := columnIndex(ref); ok {
+		if c < len(d.cells) {
+			return strings.TrimSpace(d.cells[c]), nil
+		}
+	}
+	return "", fmt.Errorf("no column %q", ref)
+}
+
+// Render the name of a row
+func (np *NamePattern) Render(data NameData) (string, error) {
+	name, err := np.Text(data)
+	if err != nil {
+		return "", err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("name pattern gives an empty name")
+	}
+	return name, nil
+}
+
+// Render the pattern as it is, spaces and line breaks included
+func (np *NamePattern) Text(data NameData) (string, error) {
+	var b strings.Builder
+	if err := np.tmpl.Execute(&b, data); err != nil {
+		// Keep the cause, not the position in the pattern
+		msg := err.Error()
+		if i := strings.LastIndex(msg, ": "); i >= 0 {
+			msg = msg[i+2:]
+		}
+		return "", fmt.Errorf("%s: %s", np.kind, msg)
+	}
+	return b.String(), nil
+}
+
+// Index of a column from its spreadsheet name: A, B, ... Z, AA...
+func columnIndex(name string) (int, bool) {
+	if name == "" || len(name) > 3 {
+		return 0, false
+	}
+	col := 0
+	for _, r := range strings.ToUpper(name) {
+		if r < 'A' || r > 'Z' {
+			return 0, false
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	return col - 1, true
+}
+
+// Lower case letters and digits joined by dashes
+func slug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// Pad the value with zeros on the left to the width
+func pad(width int, value any) string {
+	s := fmt.Sprint(value)
+	if n := utf8.RuneCountInString(s); n < width {
+		s = strings.Repeat("0", width-n) + s
+	}
+	return s
+}
+
+// Keep the first n characters of the text
+func truncate(n int, s string) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:max(n, 0)])
+}
+
+// rowNamer renders the top-level names of the rows of a table, in order
+type rowNamer struct {
+	pattern *NamePattern
+	top     int      // Column of the top level
+	headers []string // Column titles of the current sheet
+	counter int
+}
+
+// Create new rowNamer for the table, nil when no pattern is set
+func (p *FileProcessor) newRowNamer() (*rowNamer, error) {
+	if p.NamePattern == "" {
+		return nil, nil
+	}
+	pattern, err := ParseNamePattern(p.NamePattern)
+	if err != nil {
+		return nil, err
+	}
+	chain, _ := splitRoles(p.EffectiveRoles())
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return &rowNamer{pattern: pattern, top: chain[0]}, nil
+}
+
+// Is the top-level cell of the row filled, so the row gets a new top-level name
+func (n *rowNamer) filled(row []string) bool {
+	return n.top < len(row) && strings.TrimSpace(row[n.top]) != ""
+}
+
+// Render the top-level name of row r
+func (n *rowNamer) name(r int, row []string) (string, error) {
+	n.counter++
+	return n.pattern.Render(NameData{Row: r + 1, Counter: n.counter, cells: row, headers: n.headers})
+}
+
+// NamePreview renders the names of the rows as far down as they are asked for
+type NamePreview struct {
+	p     *FileProcessor
+	namer *rowNamer
+	names []string
+}
+
+// Create new NamePreview for the table, nil when no pattern is set
+func (p *FileProcessor) NewNamePreview() (*NamePreview, error) {
+	namer, err := p.newRowNamer()
+	if namer == nil {
+		return nil, err
+	}
+	return &NamePreview{p: p, namer: namer}, nil
+}
+
+// Name rendered for row r, "" when the row gets no new top-level folder
+// Errors are returned as text starting with "!".
+func (np *NamePreview) Name(r int) string {
+	for i := len(np.names); i <= r && i < np.p.NumRows(); i++ {
+		row := np.p.Row(i)
+		name := ""
+		switch {
+		case np.p.IsHeaderRow(i):
+			np.namer.headers = append([]string(nil), row...)
+		case np.namer.filled(row):
+			var err error
+			if name, err = np.namer.name(i, row); err != nil {
+				name = "! " + err.Error()
+			}
+		}
+		np.names = append(np.names, name)
+	}
+	if r < 0 || r >= len(np.names) {
+		return ""
+	}
+	return np.names[r]
+}
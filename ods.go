@@ -0,0 +1,290 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Namespaces of the OpenDocument elements read from content.xml
+const (
+	odsTableNS = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	odsTextNS  = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+)
+
+// Largest sheet LibreOffice Calc can save
+const (
+	maxODSRows = 1 << 20
+	maxODSCols = 1 << 14
+)
+
+// load ODS file, reading the selected sheets
+func (p *FileProcessor) ReadODSFile(filePath string) ([][]string, error) {
+	all, err := odsSheetNames(filePath)
+	if err != nil {
+		return nil, err
+	}
+	sheets, err := p.SelectSheets(all)
+	if err != nil {
+		return nil, err
+	}
+	// Read the rows of the selected sheets, in the order they were selected
+	found := make(map[string][][]string)
+	err = walkODSSheets(filePath, func(name string) bool {
+		return slices.Contains(sheets, name)
+	}, func(name string, rows [][]string) {
+		found[name] = rows
+	})
+	if err != nil {
+		return nil, err
+	}
+	var data [][]string
+	for _, sheetName := range sheets {
+		p.SheetRanges = append(p.SheetRanges, SheetRange{Name: sheetName, Start: len(data)})
+		data = append(data, found[sheetName]...)
+	}
+	return PadRows(data), nil
+}
+
+// Names of the sheets in an ODS file
+func odsSheetNames(filePath string) ([]string, error) {
+	var names []string
+	err := walkODSSheets(filePath, func(string) bool { return false }, func(name string, _ [][]string) {
+		names = append(names, name)
+	})
+	return names, err
+}
+
+// Call fn for each sheet of the ODS file, reading its rows only when want returns true
+func walkODSSheets(filePath string, want func(name string) bool, fn func(name string, rows [][]string)) error {
+	z, err := zip.OpenReader(filePath)
+	if err != nil {
+		return err
+	}
+	defer z.Close()
+	content, err := z.Open("content.xml")
+	if err != nil {
+		return fmt.Errorf("not an OpenDocument spreadsheet: %s", filePath)
+	}
+	defer content.Close()
+	d := xml.NewDecoder(content)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || !isODS(start.Name, odsTableNS, "table") {
+			continue
+		}
+		name := odsAttr(start, odsTableNS, "name")
+		if !want(name) {
+			if err := d.Skip(); err != nil {
+				return err
+			}
+			fn(name, nil)
+			continue
+		}
+		rows, err := readODSTable(d)
+		if err != nil {
+			return fmt.Errorf("sheet %s: %v", name, err)
+		}
+		fn(name, rows)
+	}
+}
+
+// Read the rows of a table like GetRows does for xlsx:
+// empty rows only when a filled row follows and no empty cells at the end of a row
+func readODSTable(d *xml.Decoder) ([][]string, error) {
+	var rows [][]string
+	empty := 0 // Empty rows not added yet
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case isODS(t.Name, odsTableNS, "table-row"):
+				row, err := readODSRow(d)
+				if err != nil {
+					return nil, err
+				}
+				repeat := odsRepeat(t, "number-rows-repeated")
+				// Sheets end with thousands of repeated empty rows
+				if len(row) == 0 {
+					empty += repeat
+					continue
+				}
+				if len(rows)+empty+repeat > maxODSRows {
+					return nil, fmt.Errorf("more than %d rows", maxODSRows)
+				}
+				for ; empty > 0; empty-- {
+					rows = append(rows, []string{})
+				}
+				for range repeat {
+					rows = append(rows, slices.Clone(row))
+				}
+			case isODS(t.Name, odsTableNS, "table-header-rows"),
+				isODS(t.Name, odsTableNS, "table-row-group"),
+				isODS(t.Name, odsTableNS, "table-rows"):
+				// Groups hold rows like the table itself
+			default:
+				if err := d.Skip(); err != nil {
+					return nil, err
+				}
+			}
+		case xml.EndElement:
+			if isODS(t.Name, odsTableNS, "table") {
+				return rows, nil
+			}
+		}
+	}
+}
+
+// Read the cells of a row, dropping the empty ones at the end
+func readODSRow(d *xml.Decoder) ([]string, error) {
+	var row []string
+	empty := 0 // Empty cells not added yet
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			covered := isODS(t.Name, odsTableNS, "covered-table-cell")
+			if !covered && !isODS(t.Name, odsTableNS, "table-cell") {
+				if err := d.Skip(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			text, err := readODSCell(d)
+			if err != nil {
+				return nil, err
+			}
+			// Merged cells keep the value in their first cell, like xlsx
+			if covered {
+				text = ""
+			}
+			repeat := odsRepeat(t, "number-columns-repeated")
+			if text == "" {
+				empty += repeat
+				continue
+			}
+			if len(row)+empty+repeat > maxODSCols {
+				return nil, fmt.Errorf("more than %d columns", maxODSCols)
+			}
+			for ; empty > 0; empty-- {
+				row = append(row, "")
+			}
+			for range repeat {
+				row = append(row, text)
+			}
+		case xml.EndElement:
+			return row, nil
+		}
+	}
+}
+
+// Text shown in a cell, one line per paragraph
+func readODSCell(d *xml.Decoder) (string, error) {
+	var lines []string
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			// Comments and drawings are not part of the value
+			if !isODS(t.Name, odsTextNS, "p") && !isODS(t.Name, odsTextNS, "h") {
+				if err := d.Skip(); err != nil {
+					return "", err
+				}
+				continue
+			}
+			line, err := readODSText(d)
+			if err != nil {
+				return "", err
+			}
+			lines = append(lines, line)
+		case xml.EndElement:
+			return strings.Join(lines, "\n"), nil
+		}
+	}
+}
+
+// Text of a paragraph, with spans and links flattened
+func readODSText(d *xml.Decoder) (string, error) {
+	var text strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			switch {
+			case isODS(t.Name, odsTextNS, "s"):
+				text.WriteString(strings.Repeat(" ", odsCount(odsAttr(t, odsTextNS, "c"))))
+			case isODS(t.Name, odsTextNS, "tab"):
+				text.WriteString("\t")
+			case isODS(t.Name, odsTextNS, "line-break"):
+				text.WriteString("\n")
+			case t.Name.Space == odsTextNS && t.Name.Local != "note":
+				inner, err := readODSText(d)
+				if err != nil {
+					return "", err
+				}
+				text.WriteString(inner)
+				continue
+			}
+			if err := d.Skip(); err != nil {
+				return "", err
+			}
+		case xml.EndElement:
+			return text.String(), nil
+		}
+	}
+}
+
+// Does the element have this namespace and local name
+func isODS(name xml.Name, space, local string) bool {
+	return name.Space == space && name.Local == local
+}
+
+// Value of an attribute of the element, or ""
+func odsAttr(start xml.StartElement, space, local string) string {
+	for _, attr := range start.Attr {
+		if isODS(attr.Name, space, local) {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// Number of times a row or cell is repeated
+func odsRepeat(start xml.StartElement, local string) int {
+	return odsCount(odsAttr(start, odsTableNS, local))
+}
+
+// Parse a repeat count, 1 when missing or not valid
+func odsCount(value string) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Width of a tab in an indented outline
+const outlineTabWidth = 4
+
+// Summary line printed at the end by the tree command
+var treeSummary = regexp.MustCompile(`^\d+ director(y|ies)(, \d+ files?)?$`)
+
+// Header, root and empty lines printed by the Windows tree command
+var windowsTreeLine = regexp.MustCompile(`^(Folder PATH listing( for volume .*)?|Volume serial number is .*|[A-Za-z]:(\.|\\.*)|No subfolders exist ?)$`)
+
+// load indented text file as an outline table
+// Each line is a folder; a line indented deeper than the one above is inside it.
+// A line with a file extension and nothing inside it is a file, as tree lists files.
+func (p *FileProcessor) ReadOutlineFile(filePath string) ([][]string, []ColumnRole, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	nodes, err := parseOutline(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !slices.ContainsFunc(nodes, func(node *treeNode) bool { return !node.File }) {
+		return nil, nil, fmt.Errorf("did not find any folders in the file")
+	}
+	rows, roles := treeTable(nodes)
+	return rows, roles, nil
+}
+
+// Read the folders of an outline indented with spaces, tabs, list bullets
+// or the ├── and └── prefixes printed by the tree command, +--- and \--- on Windows
+func parseOutline(r io.Reader) ([]*treeNode, error) {
+	var roots []*treeNode
+	var open []*treeNode // Folders of the current branch, outermost first
+	var widths []int     // Indentation of each folder in open
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		width, name := outlineLine(scanner.Text())
+		// Skip the root and summary lines of the tree command
+		if name == "" || name == "." || (width == 0 && (treeSummary.MatchString(name) || windowsTreeLine.MatchString(name))) {
+			continue
+		}
+		for len(widths) > 0 && widths[len(widths)-1] >= width {
+			open, widths = open[:len(open)-1], widths[:len(widths)-1]
+		}
+		node := &treeNode{Name: name}
+		if len(open) == 0 {
+			roots = append(roots, node)
+		} else {
+			parent := open[len(open)-1]
+			parent.Children = append(parent.Children, node)
+		}
+		open, widths = append(open, node), append(widths, width)
+	}
+	markFiles(roots)
+	return roots, scanner.Err()
+}
+
+// Mark the nodes with a file extension and no children as files
+func markFiles(nodes []*treeNode) {
+	for _, node := range nodes {
+		node.File = len(node.Children) == 0 && hasFileExtension(node.Name)
+		markFiles(node.Children)
+	}
+}
+
+// Does the name end in a file extension such as ".md" or ".xlsx"
+// "v1.2", "2024.01" and "My.Documents" do not.
+func hasFileExtension(name string) bool {
+	ext := filepath.Ext(name)
+	if len(ext) < 2 || len(ext) > 6 || ext == name {
+		return false
+	}
+	letter := false
+	for _, r := range ext[1:] {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			letter = true
+		case r < '0' || r > '9':
+			return false
+		}
+	}
+	return letter
+}
+
+// Indentation width and folder name of an outline line
+func outlineLine(line string) (int, string) {
+	// The tree command pads with no-break spaces
+	line = strings.TrimRight(strings.ReplaceAll(line, "\u00a0", " "), " \t\r")
+	width, i := 0, 0
+	prev := ' '
+	for i < len(line) {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		switch {
+		case r == '\t':
+			width += outlineTabWidth - width%outlineTabWidth
+		case r == ' ' || strings.ContainsRune("│├└─┬┌┃┣┗━|`", r):
+			width++
+		case r == '-' && strings.ContainsRune("|`-+\\", prev):
+			// ASCII tree: |-- and `--, +--- and \--- on Windows
+			width++
+		case (r == '+' || r == '\\') && strings.HasPrefix(line[i+size:], "-"):
+			width++
+		default:
+			size = 0
+		}
+		if size == 0 {
+			break
+		}
+		prev = r
+		i += size
+	}
+	rest := line[i:]
+	// Drop list bullets, the indentation before them sets the level
+	for _, bullet := range []string{"- ", "* ", "+ "} {
+		if strings.HasPrefix(rest, bullet) {
+			rest = strings.TrimLeft(rest[len(bullet):], " ")
+			break
+		}
+	}
+	// "src/" names a folder like "src"
+	if name := strings.TrimRight(rest, "/"); name != "" {
+		rest = name
+	}
+	return width, rest
+}
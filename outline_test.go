@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Paths of the outline nodes, files marked with a trailing "*"
+func outlinePaths(nodes []*treeNode, parent string) []string {
+	var paths []string
+	for _, node := range nodes {
+		path := parent + node.Name
+		if node.File {
+			path += "*"
+		}
+		paths = append(paths, path)
+		paths = append(paths, outlinePaths(node.Children, path+"/")...)
+	}
+	return paths
+}
+
+func TestParseOutline(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{"spaces", []string{"A", "  B", "    C", "  D", "E"}, []string{"A", "A/B", "A/B/C", "A/D", "E"}},
+		{"tabs and bullets", []string{"- A", "\t- B", "\t\t* C", "+ D"}, []string{"A", "A/B", "A/B/C", "D"}},
+		{"tree", []string{".", "├── docs", "│   └── guide", "└── src/", "", "3 directories"},
+			[]string{"docs", "docs/guide", "src"}},
+		{"tree ascii", []string{".", "|-- docs", "|   `-- guide", "`-- src"}, []string{"docs", "docs/guide", "src"}},
+		{"windows plus prefix", []string{"C:.", "+---docs", "+---src"}, []string{"docs", "src"}},
+		{"windows backslash prefix", []string{"C:.", "+---docs", "\\---src"}, []string{"docs", "src"}},
+		{"windows bar prefix", []string{"C:.", "+---docs", "|   \\---guide", "\\---src"}, []string{"docs", "docs/guide", "src"}},
+		{"windows deeper levels", []string{"C:.", "\\---a", "    +---b", "    |   \\---c", "    \\---d"},
+			[]string{"a", "a/b", "a/b/c", "a/d"}},
+		{"windows header lines", []string{"Folder PATH listing for volume Data", "Volume serial number is 0000-1234",
+			"D:\\PROJECTS", "+---docs", "\\---src", "No subfolders exist "}, []string{"docs", "src"}},
+		{"windows files", []string{"C:.", "|   notes.txt", "|", "+---docs", "|       guide.md", "|", "\\---src", "        main.go"},
+			[]string{"notes.txt*", "docs", "docs/guide.md*", "src", "src/main.go*"}},
+		{"tree files", []string{".", "├── README.md", "└── src", "    ├── main.go", "    └── v1.2"},
+			[]string{"README.md*", "src", "src/main.go*", "src/v1.2"}},
+		{"dotted folders", []string{"Q1.2024", "My.Documents", "Dr. Who", ".github", "node.js", "  x"},
+			[]string{"Q1.2024", "My.Documents", "Dr. Who", ".github", "node.js", "node.js/x"}},
+		{"plus bullet", []string{"+ A", "  + B"}, []string{"A", "A/B"}},
+		{"dash name", []string{"A", "  -B"}, []string{"A", "A/-B"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, err := parseOutline(strings.NewReader(strings.Join(tt.lines, "\n")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := outlinePaths(nodes, ""); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutlineFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string // Paths created, files marked with a trailing "*"
+	}{
+		{"files of one level", []string{"A", "  a.txt", "  b.md", "B"}, []string{"A", "A/a.txt*", "A/b.md*", "B"}},
+		{"files after subfolders", []string{"A", "  Sub", "    x.go", "  README.md"},
+			[]string{"A", "A/README.md*", "A/Sub", "A/Sub/x.go*"}},
+		{"files outside folders left out", []string{"README.md", "src", "  main.go"}, []string{"src", "src/main.go*"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			outline := filepath.Join(dir, "outline.txt")
+			if err := os.WriteFile(outline, []byte(strings.Join(tt.lines, "\n")), 0644); err != nil {
+				t.Fatal(err)
+			}
+			p := NewFileProcessor()
+			rows, roles, err := p.ReadOutlineFile(outline)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.DestPath = filepath.Join(dir, "out")
+			p.TableData, p.ColumnRoles, p.HasHeader = rows, roles, true
+			plan, err := p.BuildPlan()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, op := range plan.Operations {
+				if op.Kind != OpCreate {
+					continue
+				}
+				path, _ := filepath.Rel(p.DestPath, op.Path)
+				path = filepath.ToSlash(path)
+				if op.File {
+					path += "*"
+				}
+				got = append(got, path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Placeholder files that keep empty folders in sync tools and git
+var KeepFileNames = []string{".keep", ".gitkeep"}
+
+// Get the placeholder file name, with or without its dot, "" for none
+func ParseKeepFile(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.EqualFold(name, "none") {
+		return "", nil
+	}
+	for _, keep := range KeepFileNames {
+		if strings.EqualFold(name, keep) || strings.EqualFold(name, keep[1:]) {
+			return keep, nil
+		}
+	}
+	return "", fmt.Errorf("unknown placeholder file: %s (expected none, keep or gitkeep)", name)
+}
+
+// Create a file that must not exist yet and write the text into it
+func writeNewFile(path, content string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Put the placeholder file into the folder if it is empty
+// Returns the path of the new file, or "" when the folder is not an empty folder.
+func addKeepFile(dir, name, realDest string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) > 0 {
+		return "", nil
+	}
+	path := filepath.Join(dir, name)
+	if err := checkInside(realDest, path); err != nil {
+		return "", err
+	}
+	if err := writeNewFile(path, ""); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,557 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OpKind describes what a planned operation will do
+type OpKind int
+
+const (
+	OpCreate    OpKind = iota // The folder will be created
+	OpExists                  // The folder is already there
+	OpSkipEmpty               // The row has no top-level name
+	OpInvalid                 // The name or path cannot be used
+	OpRejected                // The row could lead outside the target path
+)
+
+// Short label of the operation kind
+func (k OpKind) String() string {
+	switch k {
+	case OpCreate:
+		return "create"
+	case OpExists:
+		return "exists"
+	case OpSkipEmpty:
+		return "skipped"
+	case OpInvalid:
+		return "invalid"
+	case OpRejected:
+		return "rejected"
+	}
+	return "unknown"
+}
+
+// Operation is a single folder or file in a plan
+type Operation struct {
+	Kind     OpKind
+	Row      int    // Row index in the table
+	Col      int    // Column index in the table
+	Name     string // Folder name taken from the cell
+	Original string // Cell value before the name was fixed, empty if unchanged
+	Path     string // Full path of the folder
+	Reason   string // Explanation for exists, skipped and invalid entries
+	Top      bool   // Folder of the top level, the template is copied into it
+	File     bool   // A file from a files column rather than a folder
+	Content  string // Text written into a new file
+}
+
+// Kind of the operation, telling files apart
+func (op Operation) KindLabel() string {
+	if op.File {
+		return op.Kind.String() + " file"
+	}
+	return op.Kind.String()
+}
+
+// Plan is the ordered list of operations computed from the table
+type Plan struct {
+	DestPath   string
+	Operations []Operation
+	Template   fs.FS            // Copied into each top-level folder, nil for none
+	Conflict   TemplateConflict // What to do with template files already in a folder
+	Warnings   []string         // Problems that did not stop planning
+	KeepFile   string           // Placeholder put in every empty folder, "" for none
+}
+
+// Count the operations of the given kind
+func (pl *Plan) Count(kind OpKind) int {
+	n := 0
+	for _, op := range pl.Operations {
+		if op.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// Path of the operation relative to the target path
+func (pl *Plan) RelPath(op Operation) string {
+	if rel, err := filepath.Rel(pl.DestPath, op.Path); err == nil {
+		return rel
+	}
+	return op.Path
+}
+
+// Operations whose name was fixed to suit the name profile
+func (pl *Plan) Renamed() []Operation {
+	var renamed []Operation
+	for _, op := range pl.Operations {
+		if op.Original != "" {
+			renamed = append(renamed, op)
+		}
+	}
+	return renamed
+}
+
+// Number of new files in the plan
+func (pl *Plan) NewFiles() int {
+	n := 0
+	for _, op := range pl.Operations {
+		if op.File && op.Kind == OpCreate {
+			n++
+		}
+	}
+	return n
+}
+
+// One line summary of the plan
+func (pl *Plan) Summary() string {
+	summary := fmt.Sprintf("%d to create, %d existing, %d skipped, %d invalid",
+		pl.Count(OpCreate), pl.Count(OpExists), pl.Count(OpSkipEmpty), pl.Count(OpInvalid))
+	if files := pl.NewFiles(); files > 0 {
+		summary = fmt.Sprintf("%d to create (%d file(s)), %d existing, %d skipped, %d invalid",
+			pl.Count(OpCreate), files, pl.Count(OpExists), pl.Count(OpSkipEmpty), pl.Count(OpInvalid))
+	}
+	if rejected := pl.Count(OpRejected); rejected > 0 {
+		summary += fmt.Sprintf(", %d rejected", rejected)
+	}
+	if renamed := len(pl.Renamed()); renamed > 0 {
+		summary += fmt.Sprintf(", %d renamed", renamed)
+	}
+	if warnings := len(pl.Warnings); warnings > 0 {
+		summary += fmt.Sprintf(", %d warning(s)", warnings)
+	}
+	return summary
+}
+
+// planBuilder collects operations while walking the table
+type planBuilder struct {
+	plan        *Plan
+	profile     NameProfile
+	autoFix     bool
+	realDest    string            // Target path with symlinks resolved
+	planned     map[string]int    // Path -> row that first listed it
+	files       map[string]bool   // Paths planned as files
+	blocked     map[string]OpKind // Invalid or rejected paths
+	namer       *rowNamer         // Renders the top-level names, nil to use the cells
+	fileText    *NamePattern      // Renders the text of new files, nil for empty files
+	headers     []string          // Column titles of the current sheet
+	expandLimit int               // Most names a cell or paths a row expands to, 0 to keep braces
+	ctx         context.Context
+	tracker     *progressTracker
+}
+
+// Create new planBuilder using the target path and name rules of the processor
+func newPlanBuilder(p *FileProcessor) *planBuilder {
+	return &planBuilder{
+		plan:        &Plan{DestPath: p.DestPath},
+		profile:     p.Profile,
+		autoFix:     p.AutoFix,
+		realDest:    realDestPath(p.DestPath),
+		planned:     make(map[string]int),
+		files:       make(map[string]bool),
+		blocked:     make(map[string]OpKind),
+		expandLimit: p.ExpandLimit,
+		ctx:         context.Background(),
+	}
+}
+
+// Plan the folder name under parent and return its path
+func (b *planBuilder) add(row, col int, parent, name string) string {
+	return b.addOp(Operation{Row: row, Col: col, Name: name}, parent)
+}
+
+// Plan the file name under parent, holding the text
+func (b *planBuilder) addFile(row, col int, parent, name, content string) {
+	b.addOp(Operation{Row: row, Col: col, Name: name, File: true, Content: content}, parent)
+}
+
+// Check the folder or file of the operation under parent and plan it
+func (b *planBuilder) addOp(op Operation, parent string) string {
+	// Check the name against the profile, fixing it if allowed
+	name := op.Name
+	nameErr := b.profile.Check(name)
+	if nameErr != nil && b.autoFix {
+		op.Original = name
+		op.Name = b.profile.Fix(name)
+		nameErr = nil
+	}
+	path := filepath.Join(parent, op.Name)
+	op.Path = path
+	if first, ok := b.planned[path]; ok {
+		op.Kind = OpExists
+		op.Reason = fmt.Sprintf("duplicate of row %d", first+1)
+		switch kind, ok := b.blocked[path]; {
+		case b.files[path] && !op.File:
+			op.Kind = OpInvalid
+			op.Reason = fmt.Sprintf("a file of row %d has this name", first+1)
+		case !b.files[path] && op.File:
+			op.Kind = OpInvalid
+			op.Reason = fmt.Sprintf("a folder of row %d has this name", first+1)
+		case ok:
+			op.Kind = kind
+			op.Reason = fmt.Sprintf("%s in row %d", kind, first+1)
+		}
+		b.plan.Operations = append(b.plan.Operations, op)
+		return path
+	}
+	b.planned[path] = op.Row
+	b.files[path] = op.File
+	parentKind, parentBlocked := b.blocked[parent]
+	switch {
+	case parentBlocked:
+		op.Kind = parentKind
+		op.Reason = fmt.Sprintf("parent folder is %s", parentKind)
+	case nameErr != nil:
+		op.Kind = OpInvalid
+		op.Reason = nameErr.Error()
+	case strings.ContainsRune(op.Name, filepath.Separator):
+		op.Kind = OpInvalid
+		op.Reason = "name contains a path separator"
+	case unsafeName(op.Name) != "":
+		op.Kind = OpRejected
+		op.Reason = unsafeName(op.Name)
+	default:
+		_, lerr := os.Lstat(path)
+		info, err := os.Stat(path)
+		switch {
+		case lerr == nil && checkInside(b.realDest, path) != nil:
+			// An existing symlink must not lead out of the target path
+			op.Kind = OpRejected
+			op.Reason = checkInside(b.realDest, path).Error()
+		case err == nil && info.IsDir() != op.File:
+			op.Kind = OpExists
+			op.Reason = "already on disk"
+		case err == nil && op.File:
+			op.Kind = OpInvalid
+			op.Reason = "a folder with this name exists"
+		case err == nil:
+			op.Kind = OpInvalid
+			op.Reason = "a file with this name exists"
+		default:
+			op.Kind = OpCreate
+		}
+	}
+	if op.Original != "" && op.Reason == "" {
+		op.Reason = fmt.Sprintf("renamed from %q", op.Original)
+	}
+	if op.Kind == OpInvalid || op.Kind == OpRejected {
+		b.blocked[path] = op.Kind
+	}
+	b.plan.Operations = append(b.plan.Operations, op)
+	return path
+}
+
+// Expand the braces of a name, warning when the cell gives too many names
+func (b *planBuilder) expand(row, col int, name string) []string {
+	if b.expandLimit <= 0 {
+		return []string{name}
+	}
+	names, capped := ExpandBraces(name, b.expandLimit)
+	if capped {
+		b.plan.Warnings = append(b.plan.Warnings, fmt.Sprintf(
+			"row %d, column %s: %q gives more than %d names, only the first %d are used",
+			row+1, ColumnName(col), name, b.expandLimit, b.expandLimit))
+	}
+	return names
+}
+
+// Render the text of a new file, empty without a file template
+func (b *planBuilder) renderFile(r int, row []string, parent, name string) (string, error) {
+	if b.fileText == nil {
+		return "", nil
+	}
+	return b.fileText.Text(NameData{Row: r + 1, Folder: filepath.Base(parent), File: name, cells: row, headers: b.headers})
+}
+
+// Record a row that does not produce any folder
+func (b *planBuilder) skip(row, col int, kind OpKind, reason string) {
+	b.plan.Operations = append(b.plan.Operations, Operation{
+		Kind: kind, Row: row, Col: col, Reason: reason,
+	})
+}
+
+// Compute every folder of the table without touching the disk
+func (p *FileProcessor) BuildPlan() (*Plan, error) {
+	return p.BuildPlanContext(context.Background(), nil)
+}
+
+// Compute the plan, reporting progress and stopping when ctx is cancelled
+func (p *FileProcessor) BuildPlanContext(ctx context.Context, progress ProgressFunc) (*Plan, error) {
+	if p.DestPath == "" {
+		return nil, fmt.Errorf("no target path selected")
+	}
+	roles := p.EffectiveRoles()
+	if chain, _ := splitRoles(roles); len(chain) == 0 && len(roles) > 0 {
+		return nil, fmt.Errorf("no column is used as a top-level name or nested level")
+	}
+	b := newPlanBuilder(p)
+	if p.Template != "" {
+		tmpl, err := OpenTemplate(p.Template)
+		if err != nil {
+			return nil, err
+		}
+		b.plan.Template, b.plan.Conflict = tmpl, p.TemplateConflict
+	}
+	if p.FileTemplate != "" {
+		text, err := os.ReadFile(p.FileTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("file template: %v", err)
+		}
+		if b.fileText, err = ParseFileTemplate(string(text)); err != nil {
+			return nil, err
+		}
+	}
+	b.plan.KeepFile = p.KeepFile
+	namer, err := p.newRowNamer()
+	if err != nil {
+		return nil, err
+	}
+	b.namer = namer
+	b.ctx = ctx
+	b.tracker = newProgressTracker(PhasePlanning, p.NumRows(), p.NumRows(), progress)
+	parts := p.TableParts()
+	part := -1
+	var rp *rowPlanner
+	// Move on to the sheets starting at row r
+	startParts := func(r int) {
+		for part+1 < len(parts) && r >= parts[part+1].Start {
+			part++
+			// Each sheet may get its own top-level folder
+			root := p.DestPath
+			if p.SheetMode == SheetFolders && parts[part].Name != "" {
+				root = b.add(parts[part].Start, -1, p.DestPath, parts[part].Name)
+			}
+			rp = newRowPlanner(b, root, roles)
+		}
+	}
+	// Rows are read one at a time so streamed tables are never fully in memory,
+	// but the plan itself is, so it is bounded.
+	err = p.EachRow(ctx, func(r int, row []string) error {
+		b.tracker.update(r+1, r+1, 0)
+		startParts(r)
+		if p.HasHeader && r == parts[part].Start {
+			// Column titles can be used in the name pattern and file template
+			b.headers = append([]string(nil), row...)
+			if b.namer != nil {
+				b.namer.headers = append([]string(nil), row...)
+			}
+			b.skip(r, 0, OpSkipEmpty, "header row")
+			return nil
+		}
+		rp.plan(r, row)
+		if p.MaxOperations > 0 && len(b.plan.Operations) > p.MaxOperations {
+			return fmt.Errorf("row %d: the table gives more than %d folders and files, split it into smaller tables",
+				r+1, p.MaxOperations)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Sheets without rows at the end of the table
+	startParts(p.NumRows())
+	b.tracker.finish()
+	return b.plan, nil
+}
+
+// Split the columns into the chain of levels and the subfolder columns
+// The chain starts with the top-level column, followed by the nested ones in column order
+func splitRoles(roles []ColumnRole) (chain, subs []int) {
+	top := -1
+	for c, role := range roles {
+		switch {
+		case role == RoleTop && top < 0:
+			top = c
+		case role == RoleTop || role == RoleNested:
+			chain = append(chain, c)
+		case role == RoleSubfolder:
+			subs = append(subs, c)
+		}
+	}
+	if top >= 0 {
+		chain = append([]int{top}, chain...)
+	}
+	return chain, subs
+}
+
+// rowPlanner turns the rows of one sheet into folders following the role of each column
+// With more than one level, each level is nested in the previous one and
+// blank cells inherit the value from the row above.
+// Subfolder columns are created side by side in the deepest level of the row,
+// and so are the files of the files columns.
+type rowPlanner struct {
+	b           *planBuilder
+	root        string
+	roles       []ColumnRole
+	chain, subs []int
+	files       []int      // Files columns
+	paths       [][]string // Paths of the current folders at each level, several when a cell expands
+	added       int        // Paths planned for the current row
+	capped      bool       // The current row reached the expand limit
+}
+
+// Create new rowPlanner for the rows under root
+func newRowPlanner(b *planBuilder, root string, roles []ColumnRole) *rowPlanner {
+	chain, subs := splitRoles(roles)
+	rp := &rowPlanner{b: b, root: root, roles: roles, chain: chain, subs: subs}
+	for c, role := range roles {
+		if role == RoleFiles {
+			rp.files = append(rp.files, c)
+		}
+	}
+	return rp
+}
+
+// Plan the folders of row r
+func (rp *rowPlanner) plan(r int, row []string) {
+	b, chain := rp.b, rp.chain
+	rp.added, rp.capped = 0, false
+	cell := func(c int) string {
+		if c < len(row) {
+			return strings.TrimSpace(row[c])
+		}
+		return ""
+	}
+	// Find the first and last filled levels of the row
+	first, last := -1, -1
+	for k, c := range chain {
+		if cell(c) != "" {
+			if first < 0 {
+				first = k
+			}
+			last = k
+		}
+	}
+	hasSubs := false
+	for _, c := range rp.subs {
+		hasSubs = hasSubs || cell(c) != ""
+	}
+	for _, c := range rp.files {
+		hasSubs = hasSubs || cell(c) != ""
+	}
+	// A single level is never inherited
+	if len(chain) == 1 {
+		rp.paths = nil
+	}
+	if first < 0 && (!hasSubs || len(rp.paths) == 0) {
+		if len(chain) == 1 {
+			b.skip(r, chain[0], OpSkipEmpty, fmt.Sprintf("column %s is empty", ColumnName(chain[0])))
+		} else {
+			b.skip(r, 0, OpSkipEmpty, "row is empty")
+		}
+		return
+	}
+	if first > len(rp.paths) {
+		b.skip(r, chain[first], OpInvalid, fmt.Sprintf("no parent folder for column %s", ColumnName(chain[first])))
+		return
+	}
+	// A filled cell replaces its level and clears the deeper ones
+	if first >= 0 {
+		rp.paths = rp.paths[:first]
+	}
+	// A new top-level folder may be named by the pattern
+	topName := ""
+	if first == 0 && b.namer != nil {
+		name, err := b.namer.name(r, row)
+		if err != nil {
+			b.skip(r, chain[0], OpInvalid, err.Error())
+			return
+		}
+		topName = name
+	}
+	if c, reason := unsafeRow(row, rp.roles); reason != "" {
+		b.skip(r, c, OpRejected, reason)
+		return
+	}
+	for k := first; k >= 0 && k <= last; k++ {
+		name := cell(chain[k])
+		if k == 0 && topName != "" {
+			name = topName
+		}
+		if name == "" {
+			b.skip(r, chain[k], OpInvalid, fmt.Sprintf("column %s is empty inside the row", ColumnName(chain[k])))
+			return
+		}
+		parents := []string{rp.root}
+		if k > 0 {
+			parents = rp.paths[k-1]
+		}
+		// An expanded cell puts each of its folders in every parent
+		var level []string
+		rp.each(r, parents, rp.expand(r, chain[k], name), func(parent, name string) {
+			level = append(level, b.add(r, chain[k], parent, name))
+			if k == 0 {
+				b.plan.Operations[len(b.plan.Operations)-1].Top = true
+			}
+		})
+		rp.paths = append(rp.paths, level)
+	}
+	// Subfolders inside the deepest level of the row
+	parents := rp.paths[len(rp.paths)-1]
+	for _, c := range rp.subs {
+		name := cell(c)
+		if name == "" {
+			continue
+		}
+		rp.each(r, parents, rp.expand(r, c, name), func(parent, name string) {
+			b.add(r, c, parent, name)
+		})
+	}
+	// Files next to them
+	for _, c := range rp.files {
+		name := cell(c)
+		if name == "" {
+			continue
+		}
+		rp.each(r, parents, rp.expand(r, c, name), func(parent, name string) {
+			content, err := b.renderFile(r, row, parent, name)
+			if err != nil {
+				b.skip(r, c, OpInvalid, fmt.Sprintf("%s: %v", name, err))
+				return
+			}
+			b.addFile(r, c, parent, name, content)
+		})
+	}
+}
+
+// Expand the braces of a cell, giving no names once the row reached the expand limit
+func (rp *rowPlanner) expand(r, c int, name string) []string {
+	if rp.capped {
+		return nil
+	}
+	return rp.b.expand(r, c, name)
+}
+
+// Plan each name in each parent until row r has as many paths as the expand limit
+// Expanded levels multiply, three cells of {1..10} in a row give 1000 folders.
+func (rp *rowPlanner) each(r int, parents, names []string, add func(parent, name string)) {
+	b := rp.b
+	for _, parent := range parents {
+		for _, name := range names {
+			if b.expandLimit > 0 && rp.added >= b.expandLimit {
+				if !rp.capped {
+					rp.capped = true
+					b.plan.Warnings = append(b.plan.Warnings, fmt.Sprintf(
+						"row %d gives more than %d folders and files, only the first %d are used",
+						r+1, b.expandLimit, b.expandLimit))
+				}
+				return
+			}
+			rp.added++
+			add(parent, name)
+		}
+	}
+}
+
+// Create the folders of a plan, returning the number of folders created
+func (p *FileProcessor) ExecutePlan(plan *Plan) (int, error) {
+	result := p.RunPlan(plan)
+	return result.Created, result.Err()
+}
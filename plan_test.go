@@ -0,0 +1,72 @@
+package main
+
+import "testing"
+
+func TestExpandLimitPerRow(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     FolderMode
+		roles    []ColumnRole
+		rows     [][]string
+		limit    int
+		want     int // Operations planned
+		warnings int
+	}{
+		{"within the limit", ModeNested, nil, [][]string{{"A{1..3}", "B{1..3}"}}, 20, 3 + 9, 0},
+		{"nested levels multiply", ModeNested, nil, [][]string{{"{1..1000}", "{1..1000}", "{1..1000}"}}, 50, 50, 3},
+		{"subfolders of every expanded folder", ModeFlat, nil, [][]string{{"Q{1..10}", "Docs", "Mail"}}, 25, 25, 1},
+		{"files of every expanded folder", ModeFlat, []ColumnRole{RoleTop, RoleFiles}, [][]string{{"Q{1..10}", "notes{1..10}.txt"}}, 30, 30, 1},
+		{"cell and row over the limit", ModeNested, nil, [][]string{{"{1..100}", "x"}}, 10, 10, 2},
+		{"limit counted per row", ModeNested, nil, [][]string{{"A{1..6}"}, {"B{1..6}"}}, 6, 12, 0},
+		{"inherited parents count", ModeNested, nil, [][]string{{"A{1..5}", ""}, {"", "Sub"}}, 3, 3 + 3, 1},
+		{"expansion off", ModeNested, nil, [][]string{{"{1..1000}", "{1..1000}"}}, 0, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewFileProcessor()
+			p.DestPath = t.TempDir()
+			p.Mode = tt.mode
+			p.ColumnRoles = tt.roles
+			p.TableData = tt.rows
+			p.ExpandLimit = tt.limit
+			plan, err := p.BuildPlan()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(plan.Operations) != tt.want || len(plan.Warnings) != tt.warnings {
+				t.Errorf("got %d operations and warnings %q, want %d operations and %d warnings",
+					len(plan.Operations), plan.Warnings, tt.want, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestMaxOperations(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    [][]string
+		max     int
+		wantErr bool
+	}{
+		{"within the limit", [][]string{{"A", "x"}, {"B", "y"}}, 4, false},
+		{"over the limit", [][]string{{"A", "x"}, {"B", "y"}, {"C", "z"}}, 4, true},
+		{"expanded row over the limit", [][]string{{"Q{1..10}"}}, 5, true},
+		{"no limit", [][]string{{"Q{1..10}", "{a,b}"}}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewFileProcessor()
+			p.DestPath = t.TempDir()
+			p.Mode = ModeNested
+			p.TableData = tt.rows
+			p.MaxOperations = tt.max
+			plan, err := p.BuildPlan()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && tt.max > 0 && len(plan.Operations) > tt.max {
+				t.Errorf("plan holds %d operations, more than %d", len(plan.Operations), tt.max)
+			}
+		})
+	}
+}
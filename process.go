@@ -1,7 +1,7 @@
 package main
 
 import (
-	"encoding/csv"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,49 +10,192 @@ import (
 	"github.com/xuri/excelize/v2"
 )
 
+// FolderMode selects how the columns of a row are turned into folders
+type FolderMode int
+
+const (
+	ModeFlat   FolderMode = iota // Columns B, C... are subfolders of column A
+	ModeNested                   // Each column is nested inside the previous one
+)
+
+// Names of the folder modes, in the order shown in the UI
+var FolderModeNames = []string{"Flat", "Nested"}
+
+// Name of the folder mode
+func (m FolderMode) String() string {
+	if int(m) >= 0 && int(m) < len(FolderModeNames) {
+		return FolderModeNames[m]
+	}
+	return "Unknown"
+}
+
+// Get the folder mode from its name, ignoring case
+func ParseFolderMode(name string) (FolderMode, error) {
+	for i, n := range FolderModeNames {
+		if strings.EqualFold(n, name) {
+			return FolderMode(i), nil
+		}
+	}
+	return ModeFlat, fmt.Errorf("unknown folder mode: %s", name)
+}
+
+// SheetMode selects how several sheets of a workbook are combined
+type SheetMode int
+
+const (
+	SheetMerge   SheetMode = iota // Rows of all sheets go to the target path
+	SheetFolders                  // Each sheet gets a top-level folder named after it
+)
+
+// Names of the sheet modes, in the order shown in the UI
+var SheetModeNames = []string{"Merge sheets", "One folder per sheet"}
+
+// SheetRange marks the rows of TableData loaded from one sheet
+type SheetRange struct {
+	Name  string
+	Start int // First row in TableData
+}
+
+// TablePart is a run of rows planned together
+type TablePart struct {
+	Name       string // Sheet name, empty for single tables
+	Start, End int
+}
+
 // FileProcessor is a struct that holds the file processing logic
 type FileProcessor struct {
-	TableFilePath string
-	DestPath      string
-	TableData     [][]string
+	TableFilePath    string
+	DestPath         string
+	TableData        [][]string
+	Sheets           []string     // Sheets to read, empty for the first one, "*" for all
+	SheetMode        SheetMode    // How several sheets are combined
+	SheetRanges      []SheetRange // Where each loaded sheet starts in TableData
+	Mode             FolderMode
+	HasHeader        bool             // The first row of each sheet holds column titles
+	ColumnRoles      []ColumnRole     // Role of each column, nil to follow Mode
+	Edited           bool             // The table was edited since it was loaded or saved
+	MappingsPath     string           // Where column mappings are saved per table file
+	Profile          NameProfile      // Rules folder names are checked against
+	AutoFix          bool             // Fix invalid names instead of rejecting them
+	ContinueOnError  bool             // Keep creating folders after a failure
+	JournalPath      string           // Where each run records the folders it created
+	StreamThreshold  int64            // Files this large are streamed, 0 to always load them
+	MaxOperations    int              // Most folders and files a plan may hold, 0 for no limit
+	Encoding         string           // Character set of CSV files, "" or "Auto" to detect it
+	CSVEncoding      string           // Character set the loaded CSV file was read with
+	Dialect          CSVDialect       // Delimiter, quote and comment of CSV files, DialectAuto to detect them
+	CSVDialect       CSVDialect       // Dialect the loaded CSV file was read with
+	Template         string           // Bundled template or folder copied into each top-level folder, "" for none
+	TemplateConflict TemplateConflict // What to do with template files already in a folder
+	NamePattern      string           // Pattern rendering the top-level name of each row, "" to use the cell
+	ExpandLimit      int              // Most names a cell and paths a row may expand to with braces, 0 to keep braces as they are
+	FileTemplate     string           // File whose text is rendered into each new file, "" for empty files
+	KeepFile         string           // Placeholder put in every empty folder, such as ".gitkeep", "" for none
+	pager            *rowPager        // Rows of a streamed table
+	streamPath       string           // File a streamed table is read from
 }
 
 // Create new FileProcessor instance
 func NewFileProcessor() *FileProcessor {
-	return &FileProcessor{}
+	return &FileProcessor{
+		Profile:         DefaultNameProfile(),
+		JournalPath:     DefaultJournalPath(),
+		MappingsPath:    DefaultMappingsPath(),
+		StreamThreshold: DefaultStreamThreshold,
+		MaxOperations:   DefaultMaxOperations,
+		Dialect:         AutoDialect(),
+		ExpandLimit:     DefaultExpandLimit,
+	}
 }
 
 // load CSV file
 func (p *FileProcessor) ReadCSVFile(filePath string) ([][]string, error) {
-	file, err := os.Open(filePath)
+	rows, err := p.openCSVRows(filePath, 0)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
-	reader := csv.NewReader(file)
-	// Allow variable number of fields
-	reader.FieldsPerRecord = -1
-	return reader.ReadAll()
+	defer rows.Close()
+	p.CSVEncoding, p.CSVDialect = rows.encoding, rows.dialect
+	data, err := rows.reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return PadRows(data), nil
 }
 
-// load XLSX file
+// load XLSX file, reading the selected sheets
 func (p *FileProcessor) ReadXLSXFile(filePath string) ([][]string, error) {
 	f, err := excelize.OpenFile(filePath)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
-	// Read the first sheet
-	sheetName := f.GetSheetName(0)
-	if sheetName == "" {
-		return nil, fmt.Errorf("did not find any sheets in the file")
-	}
-	// Read all rows from the sheet
-	rows, err := f.GetRows(sheetName)
+	sheets, err := p.SelectSheets(f.GetSheetList())
 	if err != nil {
 		return nil, err
 	}
-	// Ensure all rows have the same number of columns
+	// Read all rows from each sheet
+	var data [][]string
+	for _, sheetName := range sheets {
+		rows, err := f.GetRows(sheetName)
+		if err != nil {
+			return nil, err
+		}
+		p.SheetRanges = append(p.SheetRanges, SheetRange{Name: sheetName, Start: len(data)})
+		data = append(data, rows...)
+	}
+	return PadRows(data), nil
+}
+
+// Names of the sheets in a workbook, nil for files without sheets
+func ListSheets(filePath string) ([]string, error) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".xlsx":
+		f, err := excelize.OpenFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return f.GetSheetList(), nil
+	case ".ods":
+		return odsSheetNames(filePath)
+	case ".xls":
+		return xlsSheetNames(filePath)
+	}
+	return nil, nil
+}
+
+// Pick the sheets to read among the sheets of the workbook
+func (p *FileProcessor) SelectSheets(all []string) ([]string, error) {
+	if len(all) == 0 {
+		return nil, fmt.Errorf("did not find any sheets in the file")
+	}
+	// Read the first sheet by default
+	if len(p.Sheets) == 0 {
+		return all[:1], nil
+	}
+	var selected []string
+	for _, want := range p.Sheets {
+		if want == "*" {
+			return all, nil
+		}
+		found := false
+		for _, name := range all {
+			if strings.EqualFold(name, want) {
+				selected = append(selected, name)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("sheet not found: %s", want)
+		}
+	}
+	return selected, nil
+}
+
+// Ensure all rows have the same number of columns
+func PadRows(rows [][]string) [][]string {
 	maxCols := 0
 	for _, row := range rows {
 		if len(row) > maxCols {
@@ -64,15 +207,32 @@ func (p *FileProcessor) ReadXLSXFile(filePath string) ([][]string, error) {
 			rows[i] = append(rows[i], "")
 		}
 	}
-	return rows, nil
+	return rows
 }
 
 // Load slected file
 func (p *FileProcessor) LoadFile(filePath string) error {
 	p.TableFilePath = filePath
+	p.Edited = false
+	p.SheetRanges = nil
+	p.pager = nil
+	p.CSVEncoding, p.CSVDialect = "", CSVDialect{}
 	ext := strings.ToLower(filepath.Ext(filePath))
 
+	// Stream large files instead of holding every row in memory
+	if info, err := os.Stat(filePath); err == nil && canStream(filePath) &&
+		p.StreamThreshold > 0 && info.Size() >= p.StreamThreshold {
+		if err := p.streamFile(filePath); err != nil {
+			p.pager, p.SheetRanges = nil, nil
+			return err
+		}
+		p.TableData = nil
+		p.ApplySavedMapping()
+		return nil
+	}
+
 	var data [][]string
+	var roles []ColumnRole
 	var err error
 
 	switch ext {
@@ -80,6 +240,14 @@ func (p *FileProcessor) LoadFile(filePath string) error {
 		data, err = p.ReadCSVFile(filePath)
 	case ".xlsx":
 		data, err = p.ReadXLSXFile(filePath)
+	case ".ods":
+		data, err = p.ReadODSFile(filePath)
+	case ".xls":
+		data, err = p.ReadXLSFile(filePath)
+	case ".json", ".yaml", ".yml":
+		data, roles, err = p.ReadTreeFile(filePath)
+	case ".txt":
+		data, roles, err = p.ReadOutlineFile(filePath)
 	default:
 		err = fmt.Errorf("file not supported: %s", ext)
 	}
@@ -87,39 +255,63 @@ func (p *FileProcessor) LoadFile(filePath string) error {
 		return err
 	}
 	p.TableData = data
+	p.ApplySavedMapping()
+	// Tree files come with their own header and roles unless a mapping was saved
+	if roles != nil && p.ColumnRoles == nil {
+		p.HasHeader, p.ColumnRoles = true, roles
+	}
+	return nil
+}
+
+// Load tab-separated text, as copied from a spreadsheet, instead of a file
+func (p *FileProcessor) LoadText(text string) error {
+	data, err := newDialectReader(strings.NewReader(text), tabDialect).ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("no table data to paste")
+	}
+	p.TableFilePath = ""
+	p.Edited = false
+	p.SheetRanges = nil
+	p.pager = nil
+	p.CSVEncoding, p.CSVDialect = "", CSVDialect{}
+	p.TableData = PadRows(data)
+	p.ApplySavedMapping()
 	return nil
 }
 
 // Create folders based on the loaded table
 func (p *FileProcessor) GenerateFolders() (int, error) {
-	successCount := 0
-	for _, row := range p.TableData {
-		if len(row) == 0 || strings.TrimSpace(row[0]) == "" {
-			continue
-		}
-
-		// Create the first level folder
-		level1Path := filepath.Join(p.DestPath, strings.TrimSpace(row[0]))
-		if err := os.MkdirAll(level1Path, 0755); err != nil {
-			return successCount, fmt.Errorf("falied to create %s: %v", row[0], err)
-		}
-		successCount++
-
-		// Create subfolders if they exist
-		for i := 1; i < len(row); i++ {
-			if strings.TrimSpace(row[i]) == "" {
-				continue
-			}
+	plan, err := p.BuildPlan()
+	if err != nil {
+		return 0, err
+	}
+	return p.ExecutePlan(plan)
+}
 
-			subPath := filepath.Join(level1Path, strings.TrimSpace(row[i]))
-			if err := os.MkdirAll(subPath, 0755); err != nil {
-				return successCount, fmt.Errorf("falied to create %s: %v", row[i], err)
-			}
-			successCount++
-		}
+// Plan and create the folders, reporting progress and stopping when ctx is cancelled
+func (p *FileProcessor) GenerateFoldersContext(ctx context.Context, progress ProgressFunc) (*RunResult, error) {
+	plan, err := p.BuildPlanContext(ctx, progress)
+	if err != nil {
+		return nil, err
+	}
+	result := p.RunPlanContext(ctx, plan, progress)
+	if result.Cancelled {
+		return result, ctx.Err()
 	}
+	return result, result.Err()
+}
 
-	return successCount, nil
+// Spreadsheet style name of a column index: A, B, ... Z, AA, AB...
+func ColumnName(col int) string {
+	name := ""
+	for col >= 0 {
+		name = string(rune('A'+col%26)) + name
+		col = col/26 - 1
+	}
+	return name
 }
 
 // Clear all content in the processor
@@ -127,4 +319,27 @@ func (p *FileProcessor) Clear() {
 	p.TableFilePath = ""
 	p.DestPath = ""
 	p.TableData = [][]string{}
+	p.pager = nil
+	p.streamPath = ""
+	p.Sheets = nil
+	p.SheetRanges = nil
+	p.HasHeader = false
+	p.ColumnRoles = nil
+	p.Edited = false
+}
+
+// Split the table into the runs of rows of each loaded sheet
+func (p *FileProcessor) TableParts() []TablePart {
+	if len(p.SheetRanges) == 0 {
+		return []TablePart{{Start: 0, End: p.NumRows()}}
+	}
+	parts := make([]TablePart, len(p.SheetRanges))
+	for i, sheet := range p.SheetRanges {
+		end := p.NumRows()
+		if i+1 < len(p.SheetRanges) {
+			end = p.SheetRanges[i+1].Start
+		}
+		parts[i] = TablePart{Name: sheet.Name, Start: sheet.Start, End: end}
+	}
+	return parts
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Phases of a folder generation
+const (
+	PhasePlanning = "Planning"
+	PhaseCreating = "Creating"
+)
+
+// Shortest time between two progress reports
+const progressInterval = 100 * time.Millisecond
+
+// Progress describes how far a generation has got
+type Progress struct {
+	Phase     string
+	Done      int // Rows planned or operations run
+	Total     int
+	Rows      int // Table rows processed
+	TotalRows int
+	Created   int // Folders created so far
+	Elapsed   time.Duration
+}
+
+// ProgressFunc receives progress reports, at most every progressInterval
+type ProgressFunc func(Progress)
+
+// Part of the phase that is done, from 0 to 1
+func (pr Progress) Fraction() float64 {
+	if pr.Total <= 0 {
+		return 0
+	}
+	return float64(pr.Done) / float64(pr.Total)
+}
+
+// Estimated time left in the phase, 0 when unknown
+func (pr Progress) ETA() time.Duration {
+	if pr.Done <= 0 || pr.Done >= pr.Total {
+		return 0
+	}
+	perItem := pr.Elapsed / time.Duration(pr.Done)
+	return (perItem * time.Duration(pr.Total-pr.Done)).Round(time.Second)
+}
+
+// One line description of the progress
+func (pr Progress) String() string {
+	text := fmt.Sprintf("%s: %d/%d rows, %d folder(s) created", pr.Phase, pr.Rows, pr.TotalRows, pr.Created)
+	if eta := pr.ETA(); eta > 0 {
+		text += fmt.Sprintf(", about %s left", eta)
+	}
+	return text
+}
+
+// progressTracker sends progress reports at a limited rate
+type progressTracker struct {
+	report  ProgressFunc
+	start   time.Time
+	last    time.Time
+	current Progress
+}
+
+// Create new progressTracker, report may be nil
+func newProgressTracker(phase string, total, totalRows int, report ProgressFunc) *progressTracker {
+	return &progressTracker{
+		report:  report,
+		start:   time.Now(),
+		current: Progress{Phase: phase, Total: total, TotalRows: totalRows},
+	}
+}
+
+// Record the progress and report it if enough time has passed
+func (t *progressTracker) update(done, rows, created int) {
+	if t.report == nil {
+		return
+	}
+	t.current.Done, t.current.Rows, t.current.Created = done, rows, created
+	if now := time.Now(); now.Sub(t.last) >= progressInterval {
+		t.last = now
+		t.current.Elapsed = now.Sub(t.start)
+		t.report(t.current)
+	}
+}
+
+// Report the final state of the phase
+func (t *progressTracker) finish() {
+	if t.report == nil {
+		return
+	}
+	t.current.Elapsed = time.Since(t.start)
+	t.report(t.current)
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// ResultStatus is the outcome of a single planned folder
+type ResultStatus int
+
+const (
+	ResultCreated ResultStatus = iota // The folder was created
+	ResultExists                      // The folder was already there
+	ResultSkipped                     // The row had nothing to create
+	ResultFailed                      // The folder could not be created
+	ResultPending                     // The run stopped before this folder
+)
+
+// Short label of the result status
+func (s ResultStatus) String() string {
+	switch s {
+	case ResultCreated:
+		return "created"
+	case ResultExists:
+		return "exists"
+	case ResultSkipped:
+		return "skipped"
+	case ResultFailed:
+		return "failed"
+	case ResultPending:
+		return "pending"
+	}
+	return "unknown"
+}
+
+// ErrorKind classifies why a folder could not be created
+type ErrorKind string
+
+const (
+	ErrKindNone         ErrorKind = ""
+	ErrKindInvalid      ErrorKind = "invalid"       // Rejected while planning
+	ErrKindUnsafe       ErrorKind = "unsafe"        // Would lead outside the target path
+	ErrKindPermission   ErrorKind = "permission"    // Access denied
+	ErrKindFileExists   ErrorKind = "file-exists"   // A file is in the way
+	ErrKindNameTooLong  ErrorKind = "name-too-long" // Name or path is too long
+	ErrKindParentFailed ErrorKind = "parent-failed" // An upper level folder failed
+	ErrKindTemplate     ErrorKind = "template"      // The template could not be copied into the folder
+	ErrKindPlaceholder  ErrorKind = "placeholder"   // The placeholder file could not be put into the folder
+	ErrKindOther        ErrorKind = "other"
+)
+
+// Get the kind of a folder creation error
+func ClassifyError(err error) ErrorKind {
+	switch {
+	case err == nil:
+		return ErrKindNone
+	case errors.Is(err, fs.ErrPermission):
+		return ErrKindPermission
+	case errors.Is(err, fs.ErrExist), errors.Is(err, syscall.ENOTDIR):
+		return ErrKindFileExists
+	case errors.Is(err, syscall.ENAMETOOLONG):
+		return ErrKindNameTooLong
+	}
+	return ErrKindOther
+}
+
+// CellResult is the outcome of one operation of the plan
+type CellResult struct {
+	Row    int // Row index in the table
+	Col    int // Column index in the table
+	Path   string
+	Status ResultStatus
+	Kind   ErrorKind
+	Error  string
+}
+
+// RunResult holds the outcome of every operation of a run
+type RunResult struct {
+	Created      int
+	Files        int  // Files created from the files columns
+	Aborted      bool // The run stopped at the first failure or was cancelled
+	Cancelled    bool // The run was cancelled before the end
+	Results      []CellResult
+	Copied       []string // Files and folders copied from the template, parents first
+	Placeholders []string // Placeholder files put into empty folders
+	JournalErr   error    // The journal of the run could not be written
+}
+
+// Results that were not created because of an error, including pending ones
+func (r *RunResult) Failures() []CellResult {
+	var failures []CellResult
+	for _, res := range r.Results {
+		if res.Status == ResultFailed || res.Status == ResultPending {
+			failures = append(failures, res)
+		}
+	}
+	return failures
+}
+
+// First error met while creating folders, or nil
+func (r *RunResult) Err() error {
+	for _, res := range r.Results {
+		if res.Status == ResultFailed && res.Kind != ErrKindInvalid && res.Kind != ErrKindUnsafe && res.Kind != ErrKindParentFailed {
+			return fmt.Errorf("failed to create %s: %s", filepath.Base(res.Path), res.Error)
+		}
+	}
+	return nil
+}
+
+// Write the failures as CSV with a header row
+func (r *RunResult) WriteFailuresCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"row", "column", "path", "status", "kind", "error"})
+	for _, res := range r.Failures() {
+		writer.Write([]string{
+			strconv.Itoa(res.Row + 1),
+			ColumnName(res.Col),
+			res.Path,
+			res.Status.String(),
+			string(res.Kind),
+			res.Error,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Save the failures to a CSV file
+func (r *RunResult) SaveFailuresCSV(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if err := r.WriteFailuresCSV(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Create the folders and files of a plan and collect the outcome of every operation
+// Stops at the first failure unless ContinueOnError is set
+func (p *FileProcessor) RunPlan(plan *Plan) *RunResult {
+	return p.RunPlanContext(context.Background(), plan, nil)
+}
+
+// Run the plan, reporting progress and leaving the remaining folders pending when ctx is cancelled
+func (p *FileProcessor) RunPlanContext(ctx context.Context, plan *Plan, progress ProgressFunc) *RunResult {
+	result := &RunResult{}
+	failed := make(map[string]bool)
+	templated := make(map[string]bool)
+	var folders []CellResult // Folders that may need a placeholder, parents first
+	realDest := realDestPath(plan.DestPath)
+	tracker := newProgressTracker(PhaseCreating, len(plan.Operations), p.NumRows(), progress)
+	for i, op := range plan.Operations {
+		if !result.Aborted && ctx.Err() != nil {
+			result.Aborted = true
+			result.Cancelled = true
+		}
+		tracker.update(i+1, op.Row+1, result.Created)
+		res := CellResult{Row: op.Row, Col: op.Col, Path: op.Path}
+		switch {
+		case op.Kind == OpExists:
+			res.Status = ResultExists
+		case op.Kind == OpSkipEmpty:
+			res.Status = ResultSkipped
+		case op.Kind == OpInvalid:
+			res.Status = ResultFailed
+			res.Kind = ErrKindInvalid
+			res.Error = op.Reason
+		case op.Kind == OpRejected:
+			res.Status = ResultFailed
+			res.Kind = ErrKindUnsafe
+			res.Error = op.Reason
+		case failed[filepath.Dir(op.Path)]:
+			res.Status = ResultFailed
+			res.Kind = ErrKindParentFailed
+			res.Error = "parent folder was not created"
+			failed[op.Path] = true
+		case result.Aborted:
+			res.Status = ResultPending
+		default:
+			// Check again in case a symlink appeared since planning
+			if err := checkInside(realDest, op.Path); err != nil {
+				res.Status = ResultFailed
+				res.Kind = ErrKindUnsafe
+				res.Error = err.Error()
+				failed[op.Path] = true
+				break
+			}
+			create := func() error { return os.MkdirAll(op.Path, 0755) }
+			if op.File {
+				create = func() error { return writeNewFile(op.Path, op.Content) }
+			}
+			if err := create(); err != nil {
+				res.Status = ResultFailed
+				res.Kind = ClassifyError(err)
+				res.Error = err.Error()
+				var pathErr *fs.PathError
+				if errors.As(err, &pathErr) {
+					res.Error = pathErr.Err.Error()
+				}
+				failed[op.Path] = true
+				result.Aborted = !p.ContinueOnError
+			} else if op.File {
+				res.Status = ResultCreated
+				result.Files++
+			} else {
+				res.Status = ResultCreated
+				result.Created++
+			}
+		}
+		result.Results = append(result.Results, res)
+		if !op.File && (res.Status == ResultCreated || res.Status == ResultExists) {
+			folders = append(folders, res)
+		}
+		// Fill each top-level folder from the template once
+		if plan.Template != nil && op.Top && !templated[op.Path] && !result.Aborted &&
+			(res.Status == ResultCreated || res.Status == ResultExists) {
+			templated[op.Path] = true
+			copied, err := copyTemplate(plan.Template, op.Path, realDest, plan.Conflict)
+			result.Copied = append(result.Copied, copied...)
+			for _, path := range copied {
+				folders = append(folders, CellResult{Row: op.Row, Col: op.Col, Path: path})
+			}
+			if err != nil {
+				res.Status = ResultFailed
+				res.Kind = ErrKindTemplate
+				res.Error = "template not copied: " + err.Error()
+				var pathErr *fs.PathError
+				if errors.As(err, &pathErr) {
+					res.Path = pathErr.Path
+					res.Error = "template not copied: " + pathErr.Err.Error()
+				}
+				result.Results = append(result.Results, res)
+				result.Aborted = !p.ContinueOnError
+			}
+		}
+	}
+	// Keep the folders left empty with a placeholder file
+	if plan.KeepFile != "" && !result.Cancelled {
+		for _, res := range folders {
+			path, err := addKeepFile(res.Path, plan.KeepFile, realDest)
+			if path != "" {
+				result.Placeholders = append(result.Placeholders, path)
+			}
+			if err != nil {
+				res.Status = ResultFailed
+				res.Kind = ErrKindPlaceholder
+				res.Path = filepath.Join(res.Path, plan.KeepFile)
+				res.Error = "placeholder not created: " + err.Error()
+				result.Results = append(result.Results, res)
+			}
+		}
+	}
+	tracker.finish()
+	// Record the created folders so the run can be undone
+	// A run that added nothing keeps the journal of the last run that did.
+	if journal := NewJournal(p.TableFilePath, plan.DestPath, result); p.JournalPath != "" && !journal.Empty() {
+		result.JournalErr = journal.Save(p.JournalPath)
+	}
+	return result
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Describe why a cell value could point outside the target path, or return ""
+func unsafeName(name string) string {
+	switch {
+	case filepath.IsAbs(name) || strings.HasPrefix(name, "/") || strings.HasPrefix(name, `\`):
+		return "absolute path"
+	case filepath.VolumeName(name) != "" || isDrive(name):
+		// On Windows "C:foo" is relative to the current folder of drive C:
+		return "drive letter"
+	}
+	// Check every element, whatever the separator
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if strings.TrimSpace(part) == ".." {
+			return "reference to the parent folder"
+		}
+	}
+	return ""
+}
+
+// Does the name start with a drive such as "C:" or "C:\", but not "Q: Plans"
+// Catches Windows drives where VolumeName does not; a colon elsewhere is left to the name profile.
+func isDrive(name string) bool {
+	if len(name) < 2 || name[1] != ':' || !isASCIILetter(name[0]) {
+		return false
+	}
+	return len(name) == 2 || name[2] == '/' || name[2] == '\\'
+}
+
+// Is the byte a letter from A to Z
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// Check the folder and file cells of a row before planning them
+// Returns the offending column and the reason the whole row is rejected, or -1 and ""
+func unsafeRow(row []string, roles []ColumnRole) (int, string) {
+	for c, cell := range row {
+		if c < len(roles) && !roles[c].IsPath() {
+			continue
+		}
+		if reason := unsafeName(strings.TrimSpace(cell)); reason != "" {
+			return c, fmt.Sprintf("%q: %s", strings.TrimSpace(cell), reason)
+		}
+	}
+	return -1, ""
+}
+
+// Real location of the target path, with symlinks resolved
+func realDestPath(destPath string) string {
+	if real, err := filepath.EvalSymlinks(destPath); err == nil {
+		return real
+	}
+	if abs, err := filepath.Abs(destPath); err == nil {
+		return abs
+	}
+	return filepath.Clean(destPath)
+}
+
+// Make sure the path stays under realDest, following any symlink on the way
+func checkInside(realDest, path string) error {
+	// Find the deepest part of the path that exists
+	existing := path
+	var rest []string
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		rest = append([]string{filepath.Base(existing)}, rest...)
+		existing = parent
+	}
+	real, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return err
+	}
+	real, err = filepath.Abs(filepath.Join(append([]string{real}, rest...)...))
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(realDest, real)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return fmt.Errorf("leads outside the target path (to %s)", real)
+	}
+	return nil
+}
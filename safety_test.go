@@ -0,0 +1,40 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestUnsafeName(t *testing.T) {
+	windows := runtime.GOOS == "windows"
+	tests := []struct {
+		name      string
+		cell      string
+		want      string
+		onWindows string // Reason on Windows when it differs
+	}{
+		{"plain", "Plans", "", ""},
+		{"colon inside", "Q: Plans", "", "drive letter"},
+		{"drive", "C:", "drive letter", "drive letter"},
+		{"drive root", `C:\`, "drive letter", "absolute path"},
+		{"drive with slash", "C:/Windows", "drive letter", "absolute path"},
+		{"drive relative", "C:foo", "", "drive letter"},
+		{"absolute", "/etc", "absolute path", "absolute path"},
+		{"backslash", `\Windows`, "absolute path", "absolute path"},
+		{"unc", `\\server\share`, "absolute path", "absolute path"},
+		{"parent", "a/../..", "reference to the parent folder", "reference to the parent folder"},
+		{"parent with backslash", `a\..`, "reference to the parent folder", "reference to the parent folder"},
+		{"dots in a name", "a..b", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.want
+			if windows {
+				want = tt.onWindows
+			}
+			if got := unsafeName(tt.cell); got != want {
+				t.Errorf("unsafeName(%q) = %q, want %q", tt.cell, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+)
+
+// NameProfile selects the file system rules folder names are checked against
+type NameProfile int
+
+const (
+	ProfilePOSIX    NameProfile = iota // Linux and other Unix systems
+	ProfileWindows                     // NTFS and SMB shares opened from Windows
+	ProfileMacOS                       // APFS and HFS+ as seen from Finder
+	ProfilePortable                    // Valid on all of the above
+)
+
+// Names of the profiles, in the order shown in the UI
+var NameProfileNames = []string{"POSIX", "Windows", "macOS", "Portable"}
+
+// Longest folder name accepted by the common file systems, in bytes
+const maxNameLength = 255
+
+// Names reserved by Windows, with or without an extension
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// Name of the profile
+func (pr NameProfile) String() string {
+	if int(pr) >= 0 && int(pr) < len(NameProfileNames) {
+		return NameProfileNames[pr]
+	}
+	return "Unknown"
+}
+
+// Get the profile from its name, ignoring case
+func ParseNameProfile(name string) (NameProfile, error) {
+	for i, n := range NameProfileNames {
+		if strings.EqualFold(n, name) {
+			return NameProfile(i), nil
+		}
+	}
+	return ProfilePOSIX, fmt.Errorf("unknown name profile: %s", name)
+}
+
+// Profile of the system the program runs on
+func DefaultNameProfile() NameProfile {
+	switch runtime.GOOS {
+	case "windows":
+		return ProfileWindows
+	case "darwin":
+		return ProfileMacOS
+	}
+	return ProfilePOSIX
+}
+
+// Does the profile include the Windows rules
+func (pr NameProfile) windows() bool {
+	return pr == ProfileWindows || pr == ProfilePortable
+}
+
+// Does the profile include the macOS rules
+func (pr NameProfile) macOS() bool {
+	return pr == ProfileMacOS || pr == ProfilePortable
+}
+
+// Is the character forbidden in a folder name
+func (pr NameProfile) badRune(r rune) bool {
+	switch {
+	case r == '/' || r == 0:
+		return true
+	case pr.windows() && (r < 32 || strings.ContainsRune(`<>:"\|?*`, r)):
+		return true
+	case pr.macOS() && r == ':':
+		return true
+	}
+	return false
+}
+
+// Is the name reserved by Windows, like CON or NUL.txt
+func isWindowsReserved(name string) bool {
+	base, _, _ := strings.Cut(name, ".")
+	return windowsReservedNames[strings.ToUpper(strings.TrimRight(base, " "))]
+}
+
+// Describe why the name is not valid for the profile, or return nil
+func (pr NameProfile) Check(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("empty name")
+	case name == "." || name == "..":
+		return fmt.Errorf("reserved name %q", name)
+	case len(name) > maxNameLength:
+		return fmt.Errorf("name longer than %d bytes", maxNameLength)
+	case !utf8.ValidString(name):
+		return fmt.Errorf("name is not valid UTF-8")
+	}
+	for _, r := range name {
+		if pr.badRune(r) {
+			if r < 32 {
+				return fmt.Errorf("control character %U not allowed on %s", r, pr)
+			}
+			return fmt.Errorf("character %q not allowed on %s", r, pr)
+		}
+	}
+	if pr.windows() {
+		if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+			return fmt.Errorf("trailing dot or space not allowed on %s", pr)
+		}
+		if isWindowsReserved(name) {
+			return fmt.Errorf("%q is a reserved name on %s", name, pr)
+		}
+	}
+	return nil
+}
+
+// Turn the name into one that is valid for the profile
+func (pr NameProfile) Fix(name string) string {
+	name = strings.ToValidUTF8(name, "_")
+	name = strings.Map(func(r rune) rune {
+		if pr.badRune(r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if pr.windows() {
+		name = strings.TrimRight(name, ". ")
+		if isWindowsReserved(name) {
+			base, ext, found := strings.Cut(name, ".")
+			name = base + "_"
+			if found {
+				name += "." + ext
+			}
+		}
+	}
+	// Cut long names on a character boundary
+	if len(name) > maxNameLength {
+		cut := maxNameLength
+		for cut > 0 && !utf8.RuneStart(name[cut]) {
+			cut--
+		}
+		name = name[:cut]
+		if pr.windows() {
+			name = strings.TrimRight(name, ". ")
+		}
+	}
+	if name == "" || name == "." || name == ".." {
+		name = strings.Repeat("_", max(len(name), 1))
+	}
+	return name
+}
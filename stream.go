@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Files at least this large are streamed instead of loaded into TableData
+const DefaultStreamThreshold = 16 << 20
+
+// Most folders and files a plan may hold unless set otherwise
+// The plan stays in memory until the run ends, even for streamed tables.
+const DefaultMaxOperations = 1000000
+
+// Rows kept together in a page of a streamed table
+const pageSize = 500
+
+// Pages of a streamed table kept in memory for the preview
+const maxCachedPages = 8
+
+// RowReader returns the rows of a table one at a time
+type RowReader interface {
+	// Next returns the next row and the name of its sheet, or io.EOF at the end
+	Next() (row []string, sheet string, err error)
+	Close() error
+}
+
+// csvRowReader reads a CSV file record by record
+type csvRowReader struct {
+	file     *os.File
+	reader   *dialectReader
+	base     int64      // Offset the reader started at
+	encoding string     // Character set of the file
+	dialect  CSVDialect // Delimiter, quote and comment of the file
+}
+
+// Open a CSV file for reading rows from the byte offset
+// Offsets are only valid for UTF-8 files, others are read from the start.
+// The dialect is detected at the start of the file and reused for later offsets.
+func (p *FileProcessor) openCSVRows(filePath string, offset int64) (*csvRowReader, error) {
+	text, file, name, base, err := p.openCSVText(filePath)
+	if err != nil {
+		return nil, err
+	}
+	dialect := p.CSVDialect
+	fromStart := offset <= base
+	if !fromStart {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			file.Close()
+			return nil, err
+		}
+		text, base = file, offset
+	}
+	buffered := bufio.NewReaderSize(text, encodingSampleSize)
+	if fromStart {
+		sample, _ := buffered.Peek(encodingSampleSize)
+		dialect = SniffDialect(string(sample), p.Dialect)
+	}
+	reader := newDialectReader(buffered, dialect)
+	return &csvRowReader{file: file, reader: reader, base: base, encoding: name, dialect: dialect}, nil
+}
+
+func (r *csvRowReader) Next() ([]string, string, error) {
+	row, err := r.reader.Read()
+	return row, "", err
+}
+
+func (r *csvRowReader) Close() error {
+	return r.file.Close()
+}
+
+// Byte offset of the next row in the file, -1 when the file is converted to UTF-8
+func (r *csvRowReader) Offset() int64 {
+	if encodings[r.encoding] != nil {
+		return -1
+	}
+	return r.base + r.reader.InputOffset()
+}
+
+// xlsxRowReader reads the selected sheets of a workbook row by row
+type xlsxRowReader struct {
+	file    *excelize.File
+	sheets  []string
+	index   int // Sheet being read
+	rows    *excelize.Rows
+	empty   int // Empty rows not returned yet
+	pending []string
+}
+
+// Open a workbook for reading the rows of the selected sheets
+func (p *FileProcessor) openXLSXRows(filePath string) (*xlsxRowReader, error) {
+	f, err := excelize.OpenFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	sheets, err := p.SelectSheets(f.GetSheetList())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &xlsxRowReader{file: f, sheets: sheets}, nil
+}
+
+// Return the rows like GetRows does: empty rows only when a filled row follows
+func (r *xlsxRowReader) Next() ([]string, string, error) {
+	for r.index < len(r.sheets) {
+		sheet := r.sheets[r.index]
+		if r.empty > 0 && r.pending != nil {
+			r.empty--
+			return []string{}, sheet, nil
+		}
+		if r.pending != nil {
+			row := r.pending
+			r.pending = nil
+			return row, sheet, nil
+		}
+		if r.rows == nil {
+			rows, err := r.file.Rows(sheet)
+			if err != nil {
+				return nil, sheet, err
+			}
+			r.rows = rows
+		}
+		if !r.rows.Next() {
+			// Trailing empty rows of the sheet are dropped
+			err := r.rows.Close()
+			r.rows, r.empty = nil, 0
+			r.index++
+			if err != nil {
+				return nil, sheet, err
+			}
+			continue
+		}
+		row, err := r.rows.Columns()
+		if err != nil {
+			return nil, sheet, err
+		}
+		if len(row) == 0 {
+			r.empty++
+			continue
+		}
+		r.pending = row
+	}
+	return nil, "", io.EOF
+}
+
+func (r *xlsxRowReader) Close() error {
+	if r.rows != nil {
+		r.rows.Close()
+	}
+	return r.file.Close()
+}
+
+// Open the table file as a stream of rows
+func (p *FileProcessor) OpenRowReader(filePath string) (RowReader, error) {
+	switch ext := strings.ToLower(filepath.Ext(filePath)); ext {
+	case ".csv":
+		return p.openCSVRows(filePath, 0)
+	case ".xlsx":
+		return p.openXLSXRows(filePath)
+	default:
+		return nil, fmt.Errorf("file not supported for streaming: %s", ext)
+	}
+}
+
+// Can the file be streamed instead of loaded
+func canStream(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	return ext == ".csv" || ext == ".xlsx"
+}
+
+// rowPager gives access to the rows of a streamed table, a page at a time
+type rowPager struct {
+	open   func(page int) (RowReader, int, error) // Reader for a page and the rows to skip
+	rows   int
+	cols   int
+	pages  map[int][][]string
+	recent []int // Cached pages, most recent last
+}
+
+// Row r of the table, padded to the number of columns
+func (pg *rowPager) Row(r int) []string {
+	if r < 0 || r >= pg.rows {
+		return nil
+	}
+	page := r / pageSize
+	rows, ok := pg.pages[page]
+	if !ok {
+		var err error
+		// Keep only complete pages, a failed one is read again next time
+		if rows, err = pg.readPage(page); err == nil {
+			pg.keep(page, rows)
+		}
+	}
+	if i := r % pageSize; i < len(rows) {
+		return rows[i]
+	}
+	return make([]string, pg.cols)
+}
+
+// Read the rows of one page from the file
+func (pg *rowPager) readPage(page int) ([][]string, error) {
+	reader, skip, err := pg.open(page)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	rows := make([][]string, 0, pageSize)
+	for len(rows) < pageSize {
+		row, _, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rows, err
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		for len(row) < pg.cols {
+			row = append(row, "")
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Cache the page, forgetting the oldest ones
+func (pg *rowPager) keep(page int, rows [][]string) {
+	pg.pages[page] = rows
+	pg.recent = append(pg.recent, page)
+	for len(pg.recent) > maxCachedPages {
+		delete(pg.pages, pg.recent[0])
+		pg.recent = pg.recent[1:]
+	}
+}
+
+// Scan the file once to count rows and columns without keeping them
+// The first page is kept for the preview and header detection
+func (p *FileProcessor) streamFile(filePath string) error {
+	reader, err := p.OpenRowReader(filePath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if csvReader, ok := reader.(*csvRowReader); ok {
+		p.CSVEncoding, p.CSVDialect = csvReader.encoding, csvReader.dialect
+	}
+	pager := &rowPager{pages: make(map[int][][]string)}
+	var offsets []int64 // Start of each page in a CSV file
+	var first [][]string
+	// Every selected sheet gets a range, even the empty ones
+	var sheets []string
+	if xlsxReader, ok := reader.(*xlsxRowReader); ok {
+		sheets = xlsxReader.sheets
+	}
+	next := 0
+	startSheets := func(until string) {
+		for next < len(sheets) {
+			name := sheets[next]
+			next++
+			p.SheetRanges = append(p.SheetRanges, SheetRange{Name: name, Start: pager.rows})
+			if name == until {
+				return
+			}
+		}
+	}
+	sheet := ""
+	for {
+		if csvReader, ok := reader.(*csvRowReader); ok && pager.rows%pageSize == 0 && csvReader.Offset() >= 0 {
+			offsets = append(offsets, csvReader.Offset())
+		}
+		row, name, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if name != sheet {
+			startSheets(name)
+			sheet = name
+		}
+		if pager.rows < pageSize {
+			first = append(first, row)
+		}
+		pager.rows++
+		pager.cols = max(pager.cols, len(row))
+	}
+	startSheets("")
+	for i := range first {
+		for len(first[i]) < pager.cols {
+			first[i] = append(first[i], "")
+		}
+	}
+	pager.keep(0, first)
+	if offsets != nil {
+		pager.open = func(page int) (RowReader, int, error) {
+			reader, err := p.openCSVRows(filePath, offsets[page])
+			return reader, 0, err
+		}
+	} else {
+		// Read from the start, skipping the rows of the pages before
+		pager.open = func(page int) (RowReader, int, error) {
+			reader, err := p.OpenRowReader(filePath)
+			return reader, page * pageSize, err
+		}
+	}
+	p.pager = pager
+	p.streamPath = filePath
+	return nil
+}
+
+// Is the table streamed from its file instead of held in TableData
+func (p *FileProcessor) Streamed() bool {
+	return p.pager != nil
+}
+
+// Number of rows of the table
+func (p *FileProcessor) NumRows() int {
+	if p.pager != nil {
+		return p.pager.rows
+	}
+	return len(p.TableData)
+}
+
+// Number of columns of the table
+func (p *FileProcessor) NumCols() int {
+	if p.pager != nil {
+		return p.pager.cols
+	}
+	if len(p.TableData) == 0 {
+		return 0
+	}
+	return len(p.TableData[0])
+}
+
+// Row r of the table, read from the file if the table is streamed
+func (p *FileProcessor) Row(r int) []string {
+	if p.pager != nil {
+		return p.pager.Row(r)
+	}
+	if r < 0 || r >= len(p.TableData) {
+		return nil
+	}
+	return p.TableData[r]
+}
+
+// First rows of the table, at most n
+func (p *FileProcessor) FirstRows(n int) [][]string {
+	rows := make([][]string, 0, n)
+	for r := 0; r < n && r < p.NumRows(); r++ {
+		rows = append(rows, p.Row(r))
+	}
+	return rows
+}
+
+// Call fn for every row in order, reading the file again if the table is streamed
+// Stops at the first error fn returns.
+func (p *FileProcessor) EachRow(ctx context.Context, fn func(r int, row []string) error) error {
+	if p.pager == nil {
+		for r, row := range p.TableData {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(r, row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	reader, err := p.OpenRowReader(p.streamPath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	for r := 0; ; r++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		row, _, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(r, row); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// SyncStatus tells how a folder compares between the table and the disk
+type SyncStatus int
+
+const (
+	SyncPresent    SyncStatus = iota // In the table and on disk
+	SyncMissing                      // In the table but not on disk yet
+	SyncUnexpected                   // On disk but not in the table
+)
+
+// Short label of the sync status
+func (s SyncStatus) String() string {
+	switch s {
+	case SyncPresent:
+		return "present"
+	case SyncMissing:
+		return "missing"
+	case SyncUnexpected:
+		return "unexpected"
+	}
+	return "unknown"
+}
+
+// Folder under the target path holding the archived folders, one dated folder per day
+const ArchiveFolderName = "_Archive"
+
+// SyncEntry is one folder of the comparison
+type SyncEntry struct {
+	Status SyncStatus
+	Path   string
+	Row    int // Row that lists the folder, -1 for unexpected folders
+}
+
+// SyncReport compares the folders of a plan with those under its target path
+type SyncReport struct {
+	DestPath string
+	Entries  []SyncEntry // Folders of the table in plan order, then the unexpected ones
+}
+
+// Count the entries with the given status
+func (r *SyncReport) Count(status SyncStatus) int {
+	n := 0
+	for _, entry := range r.Entries {
+		if entry.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Folders on disk that are not in the table
+func (r *SyncReport) Unexpected() []SyncEntry {
+	var unexpected []SyncEntry
+	for _, entry := range r.Entries {
+		if entry.Status == SyncUnexpected {
+			unexpected = append(unexpected, entry)
+		}
+	}
+	return unexpected
+}
+
+// Path of the entry relative to the target path
+func (r *SyncReport) RelPath(entry SyncEntry) string {
+	if rel, err := filepath.Rel(r.DestPath, entry.Path); err == nil {
+		return rel
+	}
+	return entry.Path
+}
+
+// One line summary of the comparison
+func (r *SyncReport) Summary() string {
+	return fmt.Sprintf("%d present, %d missing, %d unexpected",
+		r.Count(SyncPresent), r.Count(SyncMissing), r.Count(SyncUnexpected))
+}
+
+// Compare the folders of the plan with the folders under its target path
+// Only the folders the plan puts other folders into are searched: what is inside
+// the deepest folders of the table belongs to their users. Hidden folders, the
+// archive and the folders of the template are left out, and so are the folders
+// of invalid or rejected cells and the unfixed names of renamed ones: the table
+// still lists them, they must not be archived.
+// A folder named with other letter case or Unicode form than the table, as
+// case-insensitive volumes and macOS allow, counts as the folder of the table.
+func CompareTree(ctx context.Context, plan *Plan) (*SyncReport, error) {
+	root := filepath.Clean(plan.DestPath)
+	report := &SyncReport{DestPath: root}
+	expected := make(map[string]bool)
+	searched := map[string]bool{root: true}
+	for _, op := range plan.Operations {
+		if op.File || op.Path == "" || expected[op.Path] {
+			continue
+		}
+		if op.Original != "" {
+			expected[filepath.Join(filepath.Dir(op.Path), op.Original)] = true
+		}
+		if op.Kind != OpCreate && op.Kind != OpExists {
+			expected[op.Path] = true
+			continue
+		}
+		expected[op.Path] = true
+		searched[filepath.Dir(op.Path)] = true
+		status := SyncPresent
+		if op.Kind == OpCreate {
+			status = SyncMissing
+		}
+		report.Entries = append(report.Entries, SyncEntry{Status: status, Path: op.Path, Row: op.Row})
+		if op.Top && plan.Template != nil {
+			templateFolders(plan.Template, op.Path, expected)
+		}
+	}
+	folded := make(map[string][]string)
+	for path := range expected {
+		folded[foldPath(path)] = append(folded[foldPath(path)], path)
+	}
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		switch {
+		case err != nil && path == root:
+			return err
+		case err != nil:
+			// Folders that cannot be read are left out
+			return nil
+		case path == root || !entry.IsDir():
+			return nil
+		case strings.HasPrefix(entry.Name(), "."), filepath.Join(root, ArchiveFolderName) == path:
+			return fs.SkipDir
+		case !expected[path]:
+			if planned := samePlanned(folded[foldPath(path)], path); planned != "" {
+				if !searched[planned] {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			report.Entries = append(report.Entries, SyncEntry{Status: SyncUnexpected, Path: path, Row: -1})
+			return fs.SkipDir
+		case !searched[path]:
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Path with letter case and Unicode form folded
+func foldPath(path string) string {
+	return strings.ToLower(norm.NFC.String(path))
+}
+
+// Planned path of the candidates naming the same folder as path, "" if none does
+func samePlanned(candidates []string, path string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	for _, candidate := range candidates {
+		if other, err := os.Stat(candidate); err == nil && os.SameFile(info, other) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// Mark the folders the template puts into dest as expected
+func templateFolders(tmpl fs.FS, dest string, expected map[string]bool) {
+	fs.WalkDir(tmpl, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.IsDir() && name != "." {
+			expected[filepath.Join(dest, filepath.FromSlash(path.Clean(name)))] = true
+		}
+		return nil
+	})
+}
+
+// ArchivedFolder is a folder moved into the archive
+type ArchivedFolder struct {
+	From, To string
+}
+
+// ArchiveResult lists the folders moved into the archive
+type ArchiveResult struct {
+	Folder string // Dated folder of the archive
+	Moved  []ArchivedFolder
+	Errors []error
+}
+
+// One line summary of the archiving
+func (r *ArchiveResult) Summary() string {
+	summary := fmt.Sprintf("%d folder(s) moved to %s", len(r.Moved), r.Folder)
+	if len(r.Errors) > 0 {
+		summary += fmt.Sprintf(", %d failed: %v", len(r.Errors), r.Errors[0])
+	}
+	return summary
+}
+
+// Move the unexpected folders into the archive folder of the day instead of deleting them
+// Each folder keeps its place under the target path, "Alpha/Old" goes to "_Archive/<date>/Alpha/Old".
+// The moved folders are dropped from the report.
+func (r *SyncReport) ArchiveUnexpected(now time.Time) *ArchiveResult {
+	folder := filepath.Join(r.DestPath, ArchiveFolderName, now.Format("2006-01-02"))
+	result := &ArchiveResult{Folder: folder}
+	realDest := realDestPath(r.DestPath)
+	var kept []SyncEntry
+	for _, entry := range r.Entries {
+		if entry.Status != SyncUnexpected {
+			kept = append(kept, entry)
+			continue
+		}
+		to := filepath.Join(folder, r.RelPath(entry))
+		if _, err := os.Lstat(to); err == nil {
+			to = freeFolderName(to)
+		}
+		err := checkInside(realDest, entry.Path)
+		if err == nil {
+			err = checkInside(realDest, to)
+		}
+		if err == nil {
+			err = os.MkdirAll(filepath.Dir(to), 0755)
+		}
+		if err == nil {
+			err = os.Rename(entry.Path, to)
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %v", r.RelPath(entry), err))
+			kept = append(kept, entry)
+			continue
+		}
+		result.Moved = append(result.Moved, ArchivedFolder{From: entry.Path, To: to})
+	}
+	r.Entries = kept
+	return result
+}
+
+// First free name of the form "name (2)" next to the folder
+func freeFolderName(target string) string {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)", target, n)
+		if _, err := os.Lstat(candidate); err != nil {
+			return candidate
+		}
+	}
+}
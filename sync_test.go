@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCompareTree(t *testing.T) {
+	tests := []struct {
+		name    string
+		planned []string          // Folders of the table
+		dirs    []string          // Folders on disk
+		links   map[string]string // Other names of folders on disk, as a case-insensitive volume gives
+		want    []string          // Unexpected folders
+	}{
+		{"exact names", []string{"Alpha", "Alpha/Sub"}, []string{"Alpha/Sub", "Alpha/Old", "Beta"}, nil, []string{"Alpha/Old", "Beta"}},
+		{"other letter case", []string{"Alpha", "Alpha/Sub"}, []string{"alpha/Sub", "alpha/Old"}, map[string]string{"Alpha": "alpha"}, []string{"alpha/Old"}},
+		{"decomposed accents", []string{"Caf\u00e9"}, []string{"Cafe\u0301"}, map[string]string{"Caf\u00e9": "Cafe\u0301"}, nil},
+		{"case-sensitive volume", []string{"Alpha"}, []string{"Alpha", "alpha"}, nil, []string{"alpha"}},
+		{"hidden and archive", []string{"Alpha"}, []string{".git", ArchiveFolderName + "/2024-01-01/Old"}, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for _, dir := range tt.dirs {
+				if err := os.MkdirAll(filepath.Join(root, filepath.FromSlash(dir)), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for link, target := range tt.links {
+				if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+					t.Skip("symbolic links not available:", err)
+				}
+			}
+			plan := &Plan{DestPath: root}
+			for i, name := range tt.planned {
+				op := Operation{Kind: OpCreate, Row: i, Path: filepath.Join(root, filepath.FromSlash(name))}
+				if _, err := os.Stat(op.Path); err == nil {
+					op.Kind = OpExists
+				}
+				plan.Operations = append(plan.Operations, op)
+			}
+			report, err := CompareTree(context.Background(), plan)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, entry := range report.Unexpected() {
+				got = append(got, filepath.ToSlash(report.RelPath(entry)))
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected folders %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Templates shipped with the application, one folder each
+//
+//go:embed all:templates
+var bundledTemplates embed.FS
+
+// Files that only keep empty folders in the bundled templates, never copied
+const keepFileName = ".keep"
+
+// TemplateConflict selects what happens to a template file already in the folder
+type TemplateConflict int
+
+const (
+	ConflictSkip      TemplateConflict = iota // Keep the existing file
+	ConflictOverwrite                         // Replace it with the template file
+	ConflictRename                            // Copy the template file under a new name
+)
+
+// Names of the conflict choices, in the order shown in the UI
+var TemplateConflictNames = []string{"Keep existing files", "Overwrite existing files", "Keep both files"}
+
+// Short names of the conflict choices for the command line
+var templateConflictKeys = []string{"skip", "overwrite", "rename"}
+
+// Get the conflict choice from its name or short name, ignoring case
+func ParseTemplateConflict(name string) (TemplateConflict, error) {
+	for i := range TemplateConflictNames {
+		if strings.EqualFold(name, TemplateConflictNames[i]) || strings.EqualFold(name, templateConflictKeys[i]) {
+			return TemplateConflict(i), nil
+		}
+	}
+	return ConflictSkip, fmt.Errorf("unknown template conflict: %s (expected skip, overwrite or rename)", name)
+}
+
+// Names of the templates shipped with the application
+func BundledTemplateNames() []string {
+	entries, _ := bundledTemplates.ReadDir("templates")
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+// Open a bundled template by name, ignoring case, or a template folder by path
+func OpenTemplate(name string) (fs.FS, error) {
+	for _, bundled := range BundledTemplateNames() {
+		if strings.EqualFold(name, bundled) {
+			return fs.Sub(bundledTemplates, path.Join("templates", bundled))
+		}
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: no bundled template or folder with this name", name)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("template %s: not a folder", name)
+	}
+	return os.DirFS(name), nil
+}
+
+// Copy the contents of the template into the folder at dest
+// Returns the files and folders that were created, parents first.
+// Nothing is written outside realDest, even through symlinks in the folder.
+func copyTemplate(tmpl fs.FS, dest, realDest string, conflict TemplateConflict) ([]string, error) {
+	var created []string
+	err := fs.WalkDir(tmpl, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == "." || entry.Name() == keepFileName {
+			return nil
+		}
+		target := filepath.Join(dest, filepath.FromSlash(name))
+		if err := checkInside(realDest, target); err != nil {
+			return err
+		}
+		info, statErr := os.Stat(target)
+		if entry.IsDir() {
+			switch {
+			case statErr == nil && info.IsDir():
+				return nil
+			case statErr == nil:
+				return &fs.PathError{Op: "mkdir", Path: target, Err: fs.ErrExist}
+			}
+			if err := os.Mkdir(target, 0755); err != nil {
+				return err
+			}
+			created = append(created, target)
+			return nil
+		}
+		if statErr == nil {
+			switch {
+			case conflict == ConflictSkip:
+				return nil
+			case conflict == ConflictRename || info.IsDir():
+				target = freeFileName(target)
+			default:
+				// Overwritten files are not recorded, undo cannot bring them back
+				return copyTemplateFile(tmpl, name, target, os.O_TRUNC)
+			}
+		}
+		if err := copyTemplateFile(tmpl, name, target, os.O_EXCL); err != nil {
+			return err
+		}
+		created = append(created, target)
+		return nil
+	})
+	return created, err
+}
+
+// Copy one file of the template, opening the target with the extra flag
+func copyTemplateFile(tmpl fs.FS, name, target string, flag int) error {
+	src, err := tmpl.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|flag, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// First free name of the form "name (2).ext" next to the file
+func freeFileName(target string) string {
+	ext := filepath.Ext(target)
+	base := strings.TrimSuffix(target, ext)
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, n, ext)
+		if _, err := os.Lstat(candidate); errors.Is(err, fs.ErrNotExist) {
+			return candidate
+		}
+	}
+}
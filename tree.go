@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// treeNode is a folder read from a tree file and the folders inside it
+type treeNode struct {
+	Name     string
+	Attrs    []treeAttr // Other keys of the node, kept as metadata
+	Children []*treeNode
+	File     bool // A file of a tree listing rather than a folder
+}
+
+// treeAttr is an attribute of a tree node
+type treeAttr struct {
+	Key, Value string
+}
+
+// treeMap is an object of a tree file with its keys in file order
+type treeMap struct {
+	Keys   []string
+	Values []any
+}
+
+// load JSON or YAML tree file as an outline table
+// Each folder is a row with its name in the column of its level, so the
+// nested roles rebuild the tree. Attributes become metadata columns.
+func (p *FileProcessor) ReadTreeFile(filePath string) ([][]string, []ColumnRole, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	var value any
+	if strings.EqualFold(filepath.Ext(filePath), ".json") {
+		value, err = parseJSONTree(data)
+	} else {
+		value, err = parseYAMLTree(data)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	nodes, err := treeNodes(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, nil, fmt.Errorf("did not find any folders in the file")
+	}
+	rows, roles := treeTable(nodes)
+	return rows, roles, nil
+}
+
+// Turn the tree into an outline table and the role of each column
+// Files of a tree listing go to a files column, on rows of their own right
+// below their folder so they inherit it; files outside any folder are left out.
+func treeTable(nodes []*treeNode) ([][]string, []ColumnRole) {
+	// Find the depth of the tree and the attribute keys, in order of appearance
+	depth := 0
+	hasFiles := false
+	var keys []string
+	index := make(map[string]int)
+	var scan func(nodes []*treeNode, level int)
+	scan = func(nodes []*treeNode, level int) {
+		for _, node := range nodes {
+			if node.File {
+				hasFiles = hasFiles || level > 0
+				continue
+			}
+			depth = max(depth, level+1)
+			for _, attr := range node.Attrs {
+				if _, ok := index[attr.Key]; !ok {
+					index[attr.Key] = len(keys)
+					keys = append(keys, attr.Key)
+				}
+			}
+			scan(node.Children, level+1)
+		}
+	}
+	scan(nodes, 0)
+	// A single level is never inherited, files need a second one
+	if hasFiles {
+		depth = max(depth, 2)
+	}
+
+	header := make([]string, depth, depth+1+len(keys))
+	roles := make([]ColumnRole, depth, depth+1+len(keys))
+	for c := range header {
+		header[c] = fmt.Sprintf("Level %d", c+1)
+		roles[c] = RoleNested
+	}
+	roles[0] = RoleTop
+	filesCol := -1
+	if hasFiles {
+		filesCol = len(header)
+		header = append(header, "Files")
+		roles = append(roles, RoleFiles)
+	}
+	attrCol := len(header)
+	for _, key := range keys {
+		header = append(header, key)
+		roles = append(roles, RoleMetadata)
+	}
+	rows := [][]string{header}
+	var add func(nodes []*treeNode, level int)
+	add = func(nodes []*treeNode, level int) {
+		// Files first, so the row above them is their folder
+		for _, node := range nodes {
+			if node.File && level > 0 {
+				row := make([]string, len(header))
+				row[filesCol] = node.Name
+				rows = append(rows, row)
+			}
+		}
+		for _, node := range nodes {
+			if node.File {
+				continue
+			}
+			row := make([]string, len(header))
+			row[level] = node.Name
+			for _, attr := range node.Attrs {
+				row[attrCol+index[attr.Key]] = attr.Value
+			}
+			rows = append(rows, row)
+			add(node.Children, level+1)
+		}
+	}
+	add(nodes, 0)
+	return rows, roles
+}
+
+// Read the folders described by a decoded tree file
+// A node is a name, an object with "name" and optional "children",
+// or an object whose keys are folder names and whose values hold their children.
+func treeNodes(value any) ([]*treeNode, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		var nodes []*treeNode
+		for _, item := range v {
+			children, err := treeNodes(item)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, children...)
+		}
+		return nodes, nil
+	case treeMap:
+		if i := v.index("name"); i >= 0 {
+			node, err := namedTreeNode(v, i)
+			if err != nil {
+				return nil, err
+			}
+			return []*treeNode{node}, nil
+		}
+		// Keys are the folder names
+		nodes := make([]*treeNode, len(v.Keys))
+		for i, key := range v.Keys {
+			children, err := treeNodes(v.Values[i])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+			nodes[i] = &treeNode{Name: key, Children: children}
+		}
+		return nodes, nil
+	default:
+		return []*treeNode{{Name: treeScalar(v)}}, nil
+	}
+}
+
+// Read a node written as an object with a "name" key
+func namedTreeNode(m treeMap, nameIndex int) (*treeNode, error) {
+	if _, ok := m.Values[nameIndex].(treeMap); ok {
+		return nil, fmt.Errorf("name must be a text, not an object")
+	}
+	if _, ok := m.Values[nameIndex].([]any); ok {
+		return nil, fmt.Errorf("name must be a text, not a list")
+	}
+	node := &treeNode{Name: treeScalar(m.Values[nameIndex])}
+	for i, key := range m.Keys {
+		switch {
+		case i == nameIndex:
+		case key == "children":
+			children, err := treeNodes(m.Values[i])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", node.Name, err)
+			}
+			node.Children = children
+		default:
+			node.Attrs = append(node.Attrs, treeAttr{Key: key, Value: treeScalar(m.Values[i])})
+		}
+	}
+	return node, nil
+}
+
+// Position of the key in the object, or -1
+func (m treeMap) index(key string) int {
+	for i, k := range m.Keys {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Text of a value; objects and lists are written as JSON
+func treeScalar(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case treeMap:
+		parts := make([]string, len(v.Keys))
+		for i, key := range v.Keys {
+			parts[i] = fmt.Sprintf("%q: %s", key, treeScalar(v.Values[i]))
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = treeScalar(item)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return fmt.Sprint(value)
+}
+
+// Decode a JSON document, keeping the order of object keys
+func parseJSONTree(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	value, err := readJSONValue(dec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid JSON: unexpected data after the end")
+	}
+	return value, nil
+}
+
+// Read the next JSON value from the decoder
+func readJSONValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('['):
+		list := []any{}
+		for dec.More() {
+			item, err := readJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		_, err := dec.Token()
+		return list, err
+	case json.Delim('{'):
+		var m treeMap
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := readJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			m.Keys = append(m.Keys, key.(string))
+			m.Values = append(m.Values, value)
+		}
+		_, err := dec.Token()
+		return m, err
+	}
+	return tok, nil
+}
+
+// Decode a YAML document, keeping the order of mapping keys
+func parseYAMLTree(data []byte) (any, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %v", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	return (&yamlReader{}).value(doc.Content[0], false)
+}
+
+// Most values a YAML file may get from its aliases, nested aliases can
+// otherwise turn a few bytes into millions of rows
+const maxYAMLAliasValues = 10000
+
+// yamlReader turns YAML nodes into values, counting those copied by aliases
+type yamlReader struct {
+	aliasValues int
+}
+
+// Turn a YAML node into the values used for JSON
+// aliased tells if the node is reached through an alias.
+func (yr *yamlReader) value(node *yaml.Node, aliased bool) (any, error) {
+	if aliased {
+		yr.aliasValues++
+		if yr.aliasValues > maxYAMLAliasValues {
+			return nil, fmt.Errorf("line %d: aliases expand to more than %d values", node.Line, maxYAMLAliasValues)
+		}
+	}
+	switch node.Kind {
+	case yaml.AliasNode:
+		return yr.value(node.Alias, true)
+	case yaml.SequenceNode:
+		list := []any{}
+		for _, item := range node.Content {
+			value, err := yr.value(item, aliased)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	case yaml.MappingNode:
+		var m treeMap
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := yr.value(node.Content[i+1], aliased)
+			if err != nil {
+				return nil, err
+			}
+			m.Keys = append(m.Keys, node.Content[i].Value)
+			m.Values = append(m.Values, value)
+		}
+		return m, nil
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return nil, nil
+		}
+		return node.Value, nil
+	}
+	return nil, fmt.Errorf("line %d: unsupported YAML content", node.Line)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"image/color"
 	"path/filepath"
@@ -28,6 +29,25 @@ type MainApp struct {
 	ThemeButton           *widget.Button
 	PreviewTable          *widget.Table
 	PreviewTableContainer *container.Scroll
+	ModeSelect            *widget.Select
+	ContinueCheck         *widget.Check
+	ProfileSelect         *widget.Select
+	FixNamesCheck         *widget.Check
+	EncodingSelect        *widget.Select
+	ExpandCheck           *widget.Check
+	TemplateSelect        *widget.Select
+	ConflictSelect        *widget.Select
+	FileTextSelect        *widget.Select
+	KeepSelect            *widget.Select
+	NameEntry             *widget.Entry
+	Names                 *NamePreview // Names rendered by the name pattern, nil without one
+	Results               *ResultsPanel
+	FolderTree            *FolderTreePanel
+	PreviewTabs           *container.AppTabs
+	Sync                  *SyncPanel
+	SelectedCell          widget.TableCellID // Selected cell of the preview, Row -1 for none
+	Editing               bool               // The selected cell is edited in place
+	focusEdit             bool               // Focus the cell editor when it is next shown
 	DarkMode              bool
 }
 
@@ -101,20 +121,60 @@ func (a *MainApp) MakeUI() {
 
 	// Create buttons
 	fileSelectButton := widget.NewButton("Select File", a.SelectTableFile)
+	pasteButton := widget.NewButton("Paste", a.PasteTable)
 	targetSelectButton := widget.NewButton("Target Path", a.SelectDestination)
+	columnsButton := widget.NewButton("Columns", a.ShowColumnMapping)
 	clearButton := widget.NewButton("Clear", a.ClearAll)
+	planButton := widget.NewButton("Preview Plan", a.ShowPlan)
 	createButton := widget.NewButton("Create", a.GenerateFolders)
+	a.ModeSelect = widget.NewSelect(FolderModeNames, a.SetFolderMode)
+	a.ModeSelect.SetSelected(a.App.Preferences().StringWithFallback("folder_mode", ModeFlat.String()))
 	exitButton := widget.NewButton("Exit", func() { a.App.Quit() })
 	// Button layout
 	buttonRow := container.NewHBox(
 		fileSelectButton,
+		pasteButton,
 		targetSelectButton,
+		columnsButton,
 		layout.NewSpacer(),
 		clearButton,
+		planButton,
+		a.ModeSelect,
 		createButton,
 		exitButton,
 	)
 
+	// Create option controls
+	a.ContinueCheck = widget.NewCheck("Continue on error", func(checked bool) {
+		a.Processor.ContinueOnError = checked
+	})
+	a.ProfileSelect = widget.NewSelect(NameProfileNames, a.SetNameProfile)
+	a.ProfileSelect.SetSelected(a.App.Preferences().StringWithFallback("name_profile", DefaultNameProfile().String()))
+	a.FixNamesCheck = widget.NewCheck("Fix names", func(checked bool) {
+		a.Processor.AutoFix = checked
+		a.PreviewTable.Refresh()
+		a.RefreshFolderTree()
+	})
+	// Expand cells such as "Q{1..4}", up to the limit saved in the preferences
+	a.ExpandCheck = widget.NewCheck("Expand {a,b}", a.SetExpand)
+	a.ExpandCheck.SetChecked(true)
+	// Character set of CSV files, detected unless chosen here
+	a.EncodingSelect = widget.NewSelect(EncodingNames, nil)
+	a.EncodingSelect.SetSelected("Auto")
+	a.EncodingSelect.OnChanged = a.SetEncoding
+	undoButton := widget.NewButton("Undo Last Run", a.UndoLastRun)
+	optionRow := container.NewHBox(
+		widget.NewLabel("Options:"),
+		a.ContinueCheck,
+		a.ProfileSelect,
+		a.FixNamesCheck,
+		a.ExpandCheck,
+		widget.NewLabel("CSV encoding:"),
+		a.EncodingSelect,
+		layout.NewSpacer(),
+		undoButton,
+	)
+
 	// Create status Lables
 	a.StatusLabel = widget.NewLabel("Ready")
 	a.StatusLabel.Wrapping = fyne.TextWrapWord
@@ -123,6 +183,19 @@ func (a *MainApp) MakeUI() {
 	a.PreviewTable = a.InitializeTable()
 	a.PreviewTableContainer = container.NewScroll(a.PreviewTable)
 
+	// Show the table and the folders it leads to in two tabs
+	a.FolderTree = NewFolderTreePanel()
+	a.Sync = a.NewSyncPanel()
+	a.PreviewTabs = container.NewAppTabs(
+		container.NewTabItem("Table", container.NewBorder(a.MakeEditRow(), nil, nil, nil, a.PreviewTableContainer)),
+		container.NewTabItem("Folders", a.FolderTree.Container),
+		container.NewTabItem("Sync", a.Sync.Container),
+	)
+	a.PreviewTabs.OnSelected = func(*container.TabItem) { a.ShowFolderTree() }
+
+	// Create results panel, hidden until a run reports failures
+	a.Results = NewResultsPanel(a.Window)
+
 	// Create the main content layout
 	contentContainer := container.NewBorder(
 		container.NewVBox(
@@ -131,13 +204,19 @@ func (a *MainApp) MakeUI() {
 			fileInfo,
 			widget.NewSeparator(),
 			buttonRow,
+			optionRow,
+			a.MakeTemplateRow(),
+			a.MakeFilesRow(),
 			widget.NewSeparator(),
 			widget.NewLabel("Preview:"),
 		),
-		a.StatusLabel,
+		container.NewVBox(
+			a.Results.Container,
+			a.StatusLabel,
+		),
 		nil,
 		nil,
-		a.PreviewTableContainer,
+		a.PreviewTabs,
 	)
 
 	fullWindow := container.New(
@@ -148,6 +227,8 @@ func (a *MainApp) MakeUI() {
 
 	// Set the content
 	a.Window.SetContent(fullWindow)
+	// Paste a table with Ctrl+V anywhere in the window
+	a.Window.Canvas().AddShortcut(&fyne.ShortcutPaste{}, func(fyne.Shortcut) { a.PasteTable() })
 
 	// 	// Update PathDisplays' width based on window size
 	// 	go func() {
@@ -203,8 +284,27 @@ func (pd *PathDisplay) RefreshColor(isDark bool) {
 	pd.Text.Refresh()
 }
 
+// Path of a file chosen in a file dialog, as the operating system writes it
+func LocalPath(uri fyne.URI) string {
+	path := uri.Path()
+	if runtime.GOOS == "windows" {
+		// Remove leading slash for Windows paths
+		if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+			path = path[1:]
+		}
+		// Replace forward slashes with backslashes for Windows compatibility
+		path = strings.ReplaceAll(path, "/", "\\")
+	}
+	return path
+}
+
 // Select a file to load table data
 func (a *MainApp) SelectTableFile() {
+	a.ConfirmReplaceTable("Select File", a.openTableFile)
+}
+
+// Show the file dialog to load table data
+func (a *MainApp) openTableFile() {
 	dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
 		// Check file type and handle errors
 		if err != nil {
@@ -214,35 +314,109 @@ func (a *MainApp) SelectTableFile() {
 		if reader == nil {
 			return
 		}
+		reader.Close()
 		// Handle the file path
-		FilePath := reader.URI().Path()
-		if runtime.GOOS == "windows" {
-			// Remove leading slash for Windows paths
-			if len(FilePath) > 2 && FilePath[0] == '/' && FilePath[2] == ':' {
-				FilePath = FilePath[1:]
-			}
-			// Replace forward slashes with backslashes for Windows compatibility
-			FilePath = strings.ReplaceAll(FilePath, "/", "\\")
-		}
+		FilePath := LocalPath(reader.URI())
 		// Set the file path to the label
 		a.FilePath.Text.Text = FilePath
 		a.FilePath.Text.Refresh()
-		a.StatusLabel.SetText("Loading...")
-		// Load the file
-		if err := a.Processor.LoadFile(FilePath); err != nil {
-			a.StatusLabel.SetText("Failed to load: " + err.Error())
-			return
+		// Let the user check how a CSV file is read, or choose the sheets of a workbook
+		load := func() { a.LoadTable(FilePath) }
+		if strings.EqualFold(filepath.Ext(FilePath), ".csv") {
+			a.PickCSVOptions(FilePath, load)
+		} else {
+			a.PickSheets(FilePath, load)
 		}
-		// Ensure the container is using the new table
-		a.PreviewTable = a.InitializeTable() // Load new data
-		a.PreviewTableContainer.Content = a.PreviewTable
-		a.AutoUpdateColumnWidths() // Update the table columns
-		a.ResetTableScroll()       // Reset the table scrollbar
-		a.PreviewTableContainer.Refresh()
-		a.StatusLabel.SetText(fmt.Sprintf("All data loaded: %d rows", len(a.Processor.TableData)))
 	}, a.Window).Show()
 }
 
+// Load the table file and refresh the preview
+func (a *MainApp) LoadTable(FilePath string) {
+	a.StatusLabel.SetText("Loading...")
+	// Load the file
+	if err := a.Processor.LoadFile(FilePath); err != nil {
+		a.StatusLabel.SetText("Failed to load: " + err.Error())
+		return
+	}
+	a.RefreshPreview()
+	status := fmt.Sprintf("All data loaded: %d rows", a.Processor.NumRows())
+	if a.Processor.Streamed() {
+		status = fmt.Sprintf("Large file: %d rows, read from the file as needed", a.Processor.NumRows())
+	}
+	if sheets := len(a.Processor.SheetRanges); sheets > 1 {
+		status += fmt.Sprintf(" from %d sheets", sheets)
+	}
+	if a.Processor.CSVEncoding != "" {
+		status += fmt.Sprintf(" (%s; %s)", a.Processor.CSVEncoding, a.Processor.CSVDialect)
+	}
+	if a.Processor.HasHeader {
+		status += ", row 1 treated as header (see Columns)"
+	}
+	a.StatusLabel.SetText(status)
+}
+
+// Load the table copied from a spreadsheet to the clipboard
+func (a *MainApp) PasteTable() {
+	text := a.App.Clipboard().Content()
+	if strings.TrimSpace(text) == "" {
+		a.StatusLabel.SetText("The clipboard is empty, copy some cells first!")
+		return
+	}
+	a.ConfirmReplaceTable("Paste", func() { a.loadPastedTable(text) })
+}
+
+// Load the pasted text as the table
+func (a *MainApp) loadPastedTable(text string) {
+	if err := a.Processor.LoadText(text); err != nil {
+		a.StatusLabel.SetText("Failed to paste: " + err.Error())
+		return
+	}
+	a.FilePath.Text.Text = "Pasted from the clipboard"
+	a.FilePath.Text.Refresh()
+	a.RefreshPreview()
+	status := fmt.Sprintf("All data pasted: %d rows", a.Processor.NumRows())
+	if a.Processor.HasHeader {
+		status += ", row 1 treated as header (see Columns)"
+	}
+	a.StatusLabel.SetText(status)
+}
+
+// Show the loaded table in the preview
+func (a *MainApp) RefreshPreview() {
+	a.Names, _ = a.Processor.NewNamePreview()
+	// Ensure the container is using the new table
+	a.PreviewTable = a.InitializeTable() // Load new data
+	a.PreviewTableContainer.Content = a.PreviewTable
+	a.AutoUpdateColumnWidths() // Update the table columns
+	a.ResetTableScroll()       // Reset the table scrollbar
+	a.PreviewTableContainer.Refresh()
+	a.RefreshFolderTree()
+}
+
+// Plan the folders again after the table or the options changed
+// The last comparison of the sync tab no longer matches them and is dropped.
+func (a *MainApp) RefreshFolderTree() {
+	if a.Sync != nil {
+		a.Sync.Show(nil)
+	}
+	a.ShowFolderTree()
+}
+
+// Plan the folders and show them in the tree tab, only done while the tab is shown
+func (a *MainApp) ShowFolderTree() {
+	if a.PreviewTabs == nil || a.PreviewTabs.SelectedIndex() != 1 {
+		return
+	}
+	switch {
+	case a.Processor.TableFilePath == "" && a.Processor.NumRows() == 0:
+		a.FolderTree.Clear("Select a file to see the folders it creates")
+	case a.Processor.DestPath == "":
+		a.FolderTree.Clear("Select a target path to see the folders it gets")
+	default:
+		a.FolderTree.Plan(a.Processor)
+	}
+}
+
 // Select a destination folder to create new folders
 func (a *MainApp) SelectDestination() {
 	dialog.NewFolderOpen(func(list fyne.ListableURI, err error) {
@@ -256,14 +430,22 @@ func (a *MainApp) SelectDestination() {
 		a.Processor.DestPath = list.Path()
 		a.DestPath.Text.Text = a.Processor.DestPath
 		a.DestPath.Text.Refresh()
+		a.RefreshFolderTree()
 		a.StatusLabel.SetText("Selected target path: " + filepath.Base(a.Processor.DestPath))
 	}, a.Window).Show()
 }
 
 // Clear all content in the table
 func (a *MainApp) ClearAll() {
-	// Reset Processor
+	a.ConfirmReplaceTable("Clear", a.clearAll)
+}
+
+// Clear the table, the paths and the results
+func (a *MainApp) clearAll() {
+	// Reset Processor, keeping the selected options
 	a.Processor = NewFileProcessor()
+	a.ApplyOptions()
+	a.Results.Hide()
 	// Reset FilePath and DestPath
 	a.FilePath.Text.Text = "No Selection"
 	a.FilePath.Text.Refresh()
@@ -276,6 +458,7 @@ func (a *MainApp) ClearAll() {
 	a.PreviewTableContainer.Content = a.PreviewTable
 	// Reset scrollbar of table container
 	a.ResetTableScroll()
+	a.RefreshFolderTree()
 	// Update status
 	a.StatusLabel.SetText("All content cleared")
 	// Cleanup ram
@@ -303,57 +486,319 @@ func (a *MainApp) ResetTableScroll() {
 	}
 }
 
-// Create table
+// Create table, editing the selected cell in place
 func (a *MainApp) InitializeTable() *widget.Table {
-	return widget.NewTable(
+	a.SelectedCell = widget.TableCellID{Row: -1, Col: -1}
+	a.Editing = false
+	table := widget.NewTable(
 		func() (int, int) {
-			if a.Processor == nil || len(a.Processor.TableData) == 0 {
+			if a.Processor == nil || a.Processor.NumRows() == 0 {
 				return 0, 0 // Check data in the Processor
 			}
-			return len(a.Processor.TableData), len(a.Processor.TableData[0])
+			// The names rendered by the pattern follow the table columns
+			if a.Names != nil {
+				return a.Processor.NumRows(), a.Processor.NumCols() + 1
+			}
+			return a.Processor.NumRows(), a.Processor.NumCols()
 		},
 		func() fyne.CanvasObject {
-			return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{})
+			entry := widget.NewEntry()
+			entry.Hide()
+			return container.NewStack(widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{}), entry)
 		},
 		func(i widget.TableCellID, o fyne.CanvasObject) {
-			label := o.(*widget.Label)
-			if a.Processor != nil &&
-				len(a.Processor.TableData) > i.Row &&
-				len(a.Processor.TableData[i.Row]) > i.Col {
-				label.SetText(a.Processor.TableData[i.Row][i.Col])
+			cellObjects := o.(*fyne.Container).Objects
+			label, entry := cellObjects[0].(*widget.Label), cellObjects[1].(*widget.Entry)
+			if a.Editing && i == a.SelectedCell {
+				a.ShowCellEditor(i, entry)
+				label.Hide()
+				return
+			}
+			entry.OnChanged, entry.OnSubmitted = nil, nil
+			entry.Hide()
+			label.Show()
+			label.Importance = widget.MediumImportance
+			label.TextStyle = fyne.TextStyle{}
+			if a.Names != nil && i.Col == a.Processor.NumCols() {
+				a.ShowRenderedName(i.Row, label)
+				return
+			}
+			// Rows of a streamed table are paged in from the file
+			var row []string
+			if a.Processor != nil {
+				row = a.Processor.Row(i.Row)
+			}
+			if len(row) > i.Col {
+				cell := row[i.Col]
+				roles := a.Processor.EffectiveRoles()
+				switch {
+				case a.Processor.IsHeaderRow(i.Row):
+					// Show column titles in bold
+					label.TextStyle.Bold = true
+				case i.Col < len(roles) && !roles[i.Col].IsPath():
+					// Dim the columns that do not become folders or files
+					label.Importance = widget.LowImportance
+				case strings.TrimSpace(cell) != "" && a.Processor.Profile.Check(strings.TrimSpace(cell)) != nil:
+					// Highlight names that break the selected profile
+					label.Importance = widget.DangerImportance
+					if a.Processor.AutoFix {
+						label.Importance = widget.WarningImportance
+					}
+				}
+				label.SetText(cell)
+				label.Refresh()
 			} else {
 				label.SetText("")
 			}
 		},
 	)
+	table.OnSelected = a.SelectCell
+	return table
 }
 
-// Generate folders and update the status label
-func (a *MainApp) GenerateFolders() {
-	// Ensure a file is selected
-	if a.Processor.TableFilePath == "" {
-		a.StatusLabel.SetText("Select a file first!")
+// Set how the columns are turned into folders and save the preference
+func (a *MainApp) SetFolderMode(name string) {
+	mode, err := ParseFolderMode(name)
+	if err != nil {
+		return
+	}
+	a.Processor.Mode = mode
+	a.App.Preferences().SetString("folder_mode", mode.String())
+	a.RefreshNames()
+}
+
+// Set the rules folder names are checked against and save the preference
+func (a *MainApp) SetNameProfile(name string) {
+	profile, err := ParseNameProfile(name)
+	if err != nil {
 		return
 	}
+	a.Processor.Profile = profile
+	a.App.Preferences().SetString("name_profile", profile.String())
+	if a.PreviewTable != nil {
+		a.PreviewTable.Refresh()
+	}
+	a.RefreshFolderTree()
+}
+
+// Turn brace expansion in cells on or off
+func (a *MainApp) SetExpand(checked bool) {
+	a.Processor.ExpandLimit = 0
+	if checked {
+		a.Processor.ExpandLimit = a.App.Preferences().IntWithFallback("expand_limit", DefaultExpandLimit)
+	}
+	a.RefreshFolderTree()
+}
+
+// Copy the state of the option controls to the processor
+func (a *MainApp) ApplyOptions() {
+	a.SetFolderMode(a.ModeSelect.Selected)
+	a.SetNameProfile(a.ProfileSelect.Selected)
+	a.Processor.ContinueOnError = a.ContinueCheck.Checked
+	a.Processor.AutoFix = a.FixNamesCheck.Checked
+	a.Processor.Encoding = a.EncodingSelect.Selected
+	a.SetExpand(a.ExpandCheck.Checked)
+	a.SetTemplate(a.TemplateSelect.Selected)
+	a.SetNamePattern(a.NameEntry.Text)
+	a.Processor.TemplateConflict, _ = ParseTemplateConflict(a.ConflictSelect.Selected)
+	a.SetFileTemplate(a.FileTextSelect.Selected)
+	a.SetKeepFile(a.KeepSelect.Selected)
+}
+
+// Set the character set of CSV files and read the loaded CSV file again with it
+func (a *MainApp) SetEncoding(name string) {
+	a.Processor.Encoding = name
+	if path := a.Processor.TableFilePath; strings.EqualFold(filepath.Ext(path), ".csv") {
+		a.ConfirmReplaceTable("Read Again", func() { a.LoadTable(path) })
+	}
+}
+
+// Check that a table and a target path are ready
+func (a *MainApp) CheckReady() bool {
+	// Ensure a file is selected or a table pasted
+	if a.Processor.TableFilePath == "" && a.Processor.NumRows() == 0 {
+		a.StatusLabel.SetText("Select a file first!")
+		return false
+	}
 	// Ensure a destination path is selected
 	if a.Processor.DestPath == "" {
 		a.StatusLabel.SetText("Select a target path first!")
-		return
+		return false
 	}
 	// Ensure there is data to process
-	if len(a.Processor.TableData) == 0 {
+	if a.Processor.NumRows() == 0 {
 		a.StatusLabel.SetText("No available data!")
+		return false
+	}
+	return true
+}
+
+// Generate folders in the background and update the status label
+func (a *MainApp) GenerateFolders() {
+	if !a.CheckReady() {
 		return
 	}
-	// Call the method to batch create folders
-	// returning the number of successes and any error encountered
-	successCount, err := a.Processor.GenerateFolders()
+	a.ExecutePlan(nil)
+}
+
+// Create the folders of the plan, or of a new plan if nil, and update the status label
+func (a *MainApp) ExecutePlan(plan *Plan) {
+	processor := a.Processor
+	var result *RunResult
+	var err error
+	a.RunWithProgress("Creating Folders", func(ctx context.Context, report ProgressFunc) {
+		if plan == nil {
+			if plan, err = processor.BuildPlanContext(ctx, report); err != nil {
+				return
+			}
+		}
+		// Call the method to batch create folders
+		// returning the outcome of every planned folder
+		result = processor.RunPlanContext(ctx, plan, report)
+	}, func() {
+		if err != nil {
+			a.StatusLabel.SetText("Error: " + err.Error())
+			return
+		}
+		a.ShowRunResult(plan, result)
+	})
+}
+
+// Show the outcome of a run in the results panel and the status label
+func (a *MainApp) ShowRunResult(plan *Plan, result *RunResult) {
+	a.Results.Show(result)
+	a.PreviewTable.Refresh()
+	a.RefreshFolderTree()
+	if result.JournalErr != nil {
+		dialog.ShowError(fmt.Errorf("this run cannot be undone: %v", result.JournalErr), a.Window)
+	}
+	renamed := ""
+	if n := len(plan.Renamed()); n > 0 {
+		renamed = fmt.Sprintf(", %d name(s) fixed (see Preview Plan)", n)
+	}
+	if n := len(plan.Warnings); n > 0 {
+		renamed += fmt.Sprintf(", %d warning(s) (see Preview Plan)", n)
+	}
+	if result.Files > 0 {
+		renamed = fmt.Sprintf(" and %d file(s)", result.Files) + renamed
+	}
+	if result.Cancelled {
+		a.StatusLabel.SetText(fmt.Sprintf("Cancelled: created %d folder(s), %d not created%s",
+			result.Created, len(result.Failures()), renamed))
+		return
+	}
+	if failures := len(result.Failures()); failures > 0 {
+		status := fmt.Sprintf("Created %d folder(s), %d failed%s", result.Created, failures, renamed)
+		if err := result.Err(); err != nil {
+			status += ". Error: " + err.Error()
+		}
+		a.StatusLabel.SetText(status)
+		return
+	}
+	a.StatusLabel.SetText(fmt.Sprintf("Successfully created %d folder(s)%s", result.Created, renamed))
+}
+
+// Remove the folders created by the last run after asking the user
+func (a *MainApp) UndoLastRun() {
+	journal, err := LoadJournal(a.Processor.JournalPath)
 	if err != nil {
-		a.StatusLabel.SetText("Error: " + err.Error())
+		a.StatusLabel.SetText("Cannot undo: " + err.Error())
 		return
 	}
-	a.PreviewTable.Refresh()
-	a.StatusLabel.SetText(fmt.Sprintf("Sucessfully created %d folder(s)", successCount))
+	if journal.Empty() {
+		a.StatusLabel.SetText("The last run did not create any folder")
+		return
+	}
+	message := fmt.Sprintf("Remove the %d folder(s) created on %s in\n%s?\n\nFolders that are not empty any more are kept.",
+		len(journal.Created), journal.Time.Format("2006-01-02 15:04"), journal.DestPath)
+	if added := len(journal.Copied) + len(journal.Placeholders); added > 0 {
+		message = fmt.Sprintf("Remove the %d folder(s) and %d template or placeholder item(s) created on %s in\n%s?\n\n"+
+			"Folders that are not empty any more and files changed since are kept.",
+			len(journal.Created), added, journal.Time.Format("2006-01-02 15:04"), journal.DestPath)
+	}
+	dialog.ShowConfirm("Undo Last Run", message, func(ok bool) {
+		if !ok {
+			return
+		}
+		result, err := UndoJournalFile(a.Processor.JournalPath)
+		if result == nil {
+			a.StatusLabel.SetText("Cannot undo: " + err.Error())
+			return
+		}
+		a.RefreshFolderTree()
+		status := "Undo: " + result.Summary()
+		if err != nil {
+			status += ". Failed to update the journal: " + err.Error()
+		}
+		a.StatusLabel.SetText(status)
+	}, a.Window)
+}
+
+// Build the plan in the background and show it
+func (a *MainApp) ShowPlan() {
+	if !a.CheckReady() {
+		return
+	}
+	processor := a.Processor
+	var plan *Plan
+	var err error
+	a.RunWithProgress("Planning", func(ctx context.Context, report ProgressFunc) {
+		plan, err = processor.BuildPlanContext(ctx, report)
+	}, func() {
+		if err != nil {
+			a.StatusLabel.SetText("Error: " + err.Error())
+			return
+		}
+		a.ShowPlanDialog(plan)
+	})
+}
+
+// Show the operations of the plan with a button to run exactly that plan
+func (a *MainApp) ShowPlanDialog(plan *Plan) {
+	list := widget.NewList(
+		func() int { return len(plan.Operations) },
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			op := plan.Operations[i]
+			text := fmt.Sprintf("Row %d  [%s]  %s", op.Row+1, op.KindLabel(), plan.RelPath(op))
+			if op.Path == "" {
+				text = fmt.Sprintf("Row %d  [%s]", op.Row+1, op.Kind)
+			}
+			if op.Reason != "" {
+				text += "  (" + op.Reason + ")"
+			}
+			label.SetText(text)
+			switch op.Kind {
+			case OpCreate:
+				label.Importance = widget.SuccessImportance
+			case OpInvalid, OpRejected:
+				label.Importance = widget.DangerImportance
+			default:
+				label.Importance = widget.MediumImportance
+			}
+			label.Refresh()
+		},
+	)
+	header := container.NewVBox(widget.NewLabel(plan.Summary()))
+	// Cells that expanded to too many names
+	for _, warning := range plan.Warnings {
+		label := widget.NewLabel("Warning: " + warning)
+		label.Importance = widget.WarningImportance
+		label.Wrapping = fyne.TextWrapWord
+		header.Add(label)
+	}
+	content := container.NewBorder(header, nil, nil, nil, list)
+	planDialog := dialog.NewCustomConfirm("Plan", "Create", "Close", content, func(ok bool) {
+		if ok {
+			a.ExecutePlan(plan)
+		}
+	}, a.Window)
+	planDialog.Resize(fyne.NewSize(550, 600))
+	planDialog.Show()
+	a.StatusLabel.SetText("Plan: " + plan.Summary())
 }
 
 // Update PathDisplay width based on the window size
@@ -365,21 +810,26 @@ func (pd *PathDisplay) UpdatePathDisplayWidth(window fyne.Window) {
 	pd.Container.SetMinSize(fyne.NewSize(targetWidth, 45))
 }
 
+// Rows measured when adjusting the column widths
+const maxMeasuredRows = 1000
+
 // Adjusts the column widths based on the content
 func (a *MainApp) AutoUpdateColumnWidths() {
 	minWidth := float32(80)
 	padding := float32(20)
-	if len(a.Processor.TableData) == 0 {
+	if a.Processor.NumRows() == 0 {
 		a.PreviewTable.SetColumnWidth(0, float32(minWidth)) // If no data, set a default width
 		return
 	}
-	numCols := len(a.Processor.TableData[0]) // Get the number of columns
+	numCols := a.Processor.NumCols() // Get the number of columns
+	// Only the first rows of a large table are measured
+	numRows := min(a.Processor.NumRows(), maxMeasuredRows)
 	// Extract each column and update its width
 	for col := 0; col < numCols; col++ {
 		maxLen := float32(0) // No length limit
 		// Extract each row in the column
-		for row := 0; row < len(a.Processor.TableData); row++ {
-			cellText := a.Processor.TableData[row][col]
+		for row := 0; row < numRows; row++ {
+			cellText := a.Processor.Row(row)[col]
 			// Use MeasureText to calculate the width of the cell
 			cellSize := fyne.MeasureText(cellText, theme.TextSize(), fyne.TextStyle{})
 			// Update the maximum width of the cell
@@ -392,6 +842,13 @@ func (a *MainApp) AutoUpdateColumnWidths() {
 		// Update column width
 		a.PreviewTable.SetColumnWidth(col, width)
 	}
+	if a.Names != nil {
+		maxLen := float32(0)
+		for row := 0; row < numRows; row++ {
+			maxLen = max(maxLen, fyne.MeasureText(a.Names.Name(row), theme.TextSize(), fyne.TextStyle{}).Width)
+		}
+		a.PreviewTable.SetColumnWidth(numCols, max(maxLen+padding, minWidth))
+	}
 }
 
 // Toggle the theme between light and dark mode
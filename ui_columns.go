@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Show the header setting and the role of each column, saving them for this table file
+func (a *MainApp) ShowColumnMapping() {
+	if a.Processor.NumRows() == 0 {
+		a.StatusLabel.SetText("Select a file first!")
+		return
+	}
+	firstRow := a.Processor.Row(0)
+	roles := a.Processor.EffectiveRoles()
+
+	headerCheck := widget.NewCheck("First row is a header", nil)
+	headerCheck.SetChecked(a.Processor.HasHeader)
+
+	// One role selector for each column, labelled with the first row
+	form := widget.NewForm()
+	selects := make([]*widget.Select, len(roles))
+	for c := range roles {
+		selects[c] = widget.NewSelect(ColumnRoleNames, nil)
+		selects[c].SetSelected(roles[c].String())
+		form.Append(fmt.Sprintf("%s  %s", ColumnName(c), firstRow[c]), selects[c])
+	}
+	resetButton := widget.NewButton("Use folder mode", func() {
+		for c, role := range DefaultRoles(a.Processor.Mode, len(roles)) {
+			selects[c].SetSelected(role.String())
+		}
+	})
+
+	content := container.NewBorder(
+		container.NewVBox(headerCheck, widget.NewSeparator()),
+		resetButton,
+		nil,
+		nil,
+		container.NewVScroll(form),
+	)
+	mappingDialog := dialog.NewCustomConfirm("Columns", "Apply", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		a.Processor.HasHeader = headerCheck.Checked
+		a.Processor.ColumnRoles = make([]ColumnRole, len(selects))
+		for c, sel := range selects {
+			role, err := ParseColumnRole(sel.Selected)
+			if err != nil {
+				role = RoleIgnore
+			}
+			a.Processor.ColumnRoles[c] = role
+		}
+		a.RefreshNames()
+		if err := a.Processor.SaveMapping(); err != nil {
+			a.StatusLabel.SetText("Column roles applied, but could not be saved: " + err.Error())
+			return
+		}
+		if a.Processor.TableFilePath == "" {
+			// Pasted tables have no file to save the roles for
+			a.StatusLabel.SetText("Column roles applied")
+			return
+		}
+		a.StatusLabel.SetText("Column roles saved for " + a.Processor.TableFilePath)
+	}, a.Window)
+	mappingDialog.Resize(fyne.NewSize(450, 500))
+	mappingDialog.Show()
+}
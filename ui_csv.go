@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Rows of the file shown in the import options
+const csvPreviewRows = 8
+
+// Show the detected delimiter, quote and comment of a CSV file, let the user
+// change them, then call load
+func (a *MainApp) PickCSVOptions(filePath string, load func()) {
+	a.Processor.Dialect = AutoDialect()
+	detected, sample, err := a.Processor.SniffCSVFile(filePath)
+	if err != nil {
+		// Let LoadFile report the error
+		load()
+		return
+	}
+
+	preview := widget.NewLabel("")
+	preview.TextStyle = fyne.TextStyle{Monospace: true}
+	delimiterSelect := widget.NewSelect(DelimiterNames[1:], nil)
+	quoteSelect := widget.NewSelect(QuoteNames[1:], nil)
+	commentSelect := widget.NewSelect(CommentNames[1:], nil)
+	chosen := func() CSVDialect {
+		d, _ := ParseDialect(delimiterSelect.Selected, quoteSelect.Selected, commentSelect.Selected)
+		return d
+	}
+	// Show the first rows as they would be read
+	updatePreview := func(string) {
+		rows, _ := newDialectReader(strings.NewReader(sample), chosen()).ReadAll()
+		lines := make([]string, 0, csvPreviewRows)
+		for _, row := range rows[:min(len(rows), csvPreviewRows)] {
+			lines = append(lines, strings.Join(row, " | "))
+		}
+		preview.SetText(strings.Join(lines, "\n"))
+	}
+	delimiterSelect.SetSelected(dialectName(DelimiterNames, delimiterRunes, detected.Comma))
+	quoteSelect.SetSelected(dialectName(QuoteNames, quoteRunes, detected.Quote))
+	commentSelect.SetSelected(dialectName(CommentNames, commentRunes, detected.Comment))
+	delimiterSelect.OnChanged = updatePreview
+	quoteSelect.OnChanged = updatePreview
+	commentSelect.OnChanged = updatePreview
+	updatePreview("")
+
+	content := container.NewBorder(
+		widget.NewForm(
+			widget.NewFormItem("Delimiter:", delimiterSelect),
+			widget.NewFormItem("Quote:", quoteSelect),
+			widget.NewFormItem("Comment lines:", commentSelect),
+		),
+		nil,
+		nil,
+		nil,
+		container.NewScroll(preview),
+	)
+	optionsDialog := dialog.NewCustomConfirm("Import Options", "Load", "Cancel", content, func(ok bool) {
+		if !ok {
+			a.StatusLabel.SetText("Loading cancelled")
+			return
+		}
+		a.Processor.Dialect = chosen()
+		load()
+	}, a.Window)
+	optionsDialog.Resize(fyne.NewSize(600, 450))
+	optionsDialog.Show()
+}
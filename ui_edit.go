@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Create the buttons that edit the table
+func (a *MainApp) MakeEditRow() fyne.CanvasObject {
+	return container.NewHBox(
+		widget.NewButton("Add Row", a.AddRow),
+		widget.NewButton("Delete Row", a.DeleteRow),
+		widget.NewButton("Add Column", a.AddColumn),
+		widget.NewButton("Delete Column", a.DeleteColumn),
+		widget.NewButton("Save Table", a.SaveTable),
+	)
+}
+
+// Select a cell of the preview and start editing it
+func (a *MainApp) SelectCell(id widget.TableCellID) {
+	a.SelectedCell = id
+	computed := id.Col >= a.Processor.NumCols()
+	a.Editing = !a.Processor.Streamed() && !computed
+	a.focusEdit = a.Editing
+	a.PreviewTable.Refresh()
+	switch {
+	case computed:
+		a.StatusLabel.SetText("Names come from the name pattern, edit the pattern or the cells instead")
+	case !a.Editing:
+		a.StatusLabel.SetText("Cannot edit: " + errStreamedEdit.Error())
+	}
+}
+
+// Show the editor of the selected cell, writing each change to the table
+func (a *MainApp) ShowCellEditor(id widget.TableCellID, entry *widget.Entry) {
+	var text string
+	if row := a.Processor.Row(id.Row); id.Col < len(row) {
+		text = row[id.Col]
+	}
+	entry.OnChanged = nil
+	if entry.Text != text {
+		entry.SetText(text)
+	}
+	entry.OnChanged = func(value string) {
+		if err := a.Processor.SetCell(id.Row, id.Col, value); err != nil {
+			a.StatusLabel.SetText("Cannot edit: " + err.Error())
+		}
+		// Names are rendered again when the table is next drawn
+		a.Names, _ = a.Processor.NewNamePreview()
+		// The comparison no longer matches the table
+		if a.Sync != nil && a.Sync.Report != nil {
+			a.Sync.Show(nil)
+		}
+	}
+	// Enter ends editing and shows the cell checked against the name rules
+	entry.OnSubmitted = func(string) {
+		a.Editing = false
+		a.PreviewTable.Refresh()
+		a.AutoUpdateColumnWidths()
+		a.RefreshFolderTree()
+	}
+	entry.Show()
+	if a.focusEdit {
+		a.focusEdit = false
+		a.Window.Canvas().Focus(entry)
+	}
+}
+
+// Apply an edit to the table and show the result
+func (a *MainApp) EditTable(edit func() error, done string) bool {
+	if a.Processor.NumRows() == 0 {
+		a.StatusLabel.SetText("Select a file first!")
+		return false
+	}
+	if err := edit(); err != nil {
+		a.StatusLabel.SetText("Cannot edit: " + err.Error())
+		return false
+	}
+	a.Editing = false
+	a.PreviewTable.UnselectAll()
+	a.SelectedCell = widget.TableCellID{Row: -1, Col: -1}
+	a.RefreshNames()
+	a.StatusLabel.SetText(done)
+	return true
+}
+
+// Add an empty row below the selected cell, or at the end
+func (a *MainApp) AddRow() {
+	r := a.Processor.NumRows()
+	if a.SelectedCell.Row >= 0 {
+		r = a.SelectedCell.Row + 1
+	}
+	if a.EditTable(func() error { return a.Processor.InsertRow(r) }, fmt.Sprintf("Row %d added", r+1)) {
+		a.PreviewTable.ScrollTo(widget.TableCellID{Row: r, Col: 0})
+		a.PreviewTable.Select(widget.TableCellID{Row: r, Col: 0})
+	}
+}
+
+// Delete the row of the selected cell
+func (a *MainApp) DeleteRow() {
+	r := a.SelectedCell.Row
+	if r < 0 {
+		a.StatusLabel.SetText("Select a cell of the row to delete first!")
+		return
+	}
+	a.EditTable(func() error { return a.Processor.DeleteRow(r) }, fmt.Sprintf("Row %d deleted", r+1))
+}
+
+// Add an empty column right of the selected cell, or at the end
+func (a *MainApp) AddColumn() {
+	c := a.Processor.NumCols()
+	if a.SelectedCell.Col >= 0 {
+		c = min(a.SelectedCell.Col+1, c)
+	}
+	a.EditTable(func() error { return a.Processor.InsertColumn(c) }, fmt.Sprintf("Column %s added", ColumnName(c)))
+}
+
+// Delete the column of the selected cell
+func (a *MainApp) DeleteColumn() {
+	c := a.SelectedCell.Col
+	if c < 0 {
+		a.StatusLabel.SetText("Select a cell of the column to delete first!")
+		return
+	}
+	a.EditTable(func() error { return a.Processor.DeleteColumn(c) }, fmt.Sprintf("Column %s deleted", ColumnName(c)))
+}
+
+// Replace the table right away, or after asking when that drops edits not saved yet
+func (a *MainApp) ConfirmReplaceTable(title string, replace func()) {
+	if !a.Processor.Edited {
+		replace()
+		return
+	}
+	dialog.ShowConfirm(title, "The table has edits that are not saved.\nDiscard them?", func(ok bool) {
+		if ok {
+			replace()
+		}
+	}, a.Window)
+}
+
+// Save the edited table to a CSV or XLSX file
+func (a *MainApp) SaveTable() {
+	if a.Processor.NumRows() == 0 {
+		a.StatusLabel.SetText("Select a file first!")
+		return
+	}
+	if a.Processor.Streamed() {
+		a.StatusLabel.SetText("Cannot save: " + errStreamedEdit.Error())
+		return
+	}
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			a.StatusLabel.SetText("Cannot save: " + err.Error())
+			return
+		}
+		if writer == nil {
+			return
+		}
+		writer.Close()
+		filePath := LocalPath(writer.URI())
+		if err := a.Processor.SaveTable(filePath); err != nil {
+			a.StatusLabel.SetText("Cannot save: " + err.Error())
+			return
+		}
+		a.FilePath.Text.Text = filePath
+		a.FilePath.Text.Refresh()
+		a.StatusLabel.SetText("Table saved to " + filePath)
+	}, a.Window)
+	saveDialog.SetFileName(saveFileName(a.Processor.TableFilePath))
+	// Start next to the table file
+	if tablePath := a.Processor.TableFilePath; tablePath != "" {
+		if dir, err := storage.ListerForURI(storage.NewFileURI(filepath.Dir(tablePath))); err == nil {
+			saveDialog.SetLocation(dir)
+		}
+	}
+	saveDialog.Show()
+}
+
+// Suggested name to save the table as: the table file itself when it is
+// a CSV or XLSX file, otherwise a workbook named after it
+func saveFileName(tablePath string) string {
+	if tablePath == "" {
+		return "table.xlsx"
+	}
+	name := filepath.Base(tablePath)
+	switch ext := filepath.Ext(name); strings.ToLower(ext) {
+	case ".csv", ".xlsx":
+		return name
+	default:
+		return strings.TrimSuffix(name, ext) + ".xlsx"
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Choices of the file text and placeholder selects besides the files themselves
+const (
+	emptyFiles     = "Empty files"
+	chooseFileText = "Choose text file..."
+	noKeepFile     = "Leave empty"
+)
+
+// Create the controls for the files of the files columns and the empty folders
+func (a *MainApp) MakeFilesRow() fyne.CanvasObject {
+	a.FileTextSelect = widget.NewSelect(fileTextChoices(""), nil)
+	a.FileTextSelect.SetSelected(emptyFiles)
+	a.FileTextSelect.OnChanged = a.SetFileTemplate
+	a.KeepSelect = widget.NewSelect(append([]string{noKeepFile}, KeepFileNames...), a.SetKeepFile)
+	a.KeepSelect.SetSelected(noKeepFile)
+	return container.NewHBox(
+		widget.NewLabel("New files:"),
+		a.FileTextSelect,
+		widget.NewLabel("Empty folders:"),
+		a.KeepSelect,
+	)
+}
+
+// Choices of the file text select, with the chosen text file if any
+func fileTextChoices(file string) []string {
+	choices := []string{emptyFiles}
+	if file != "" {
+		choices = append(choices, file)
+	}
+	return append(choices, chooseFileText)
+}
+
+// Set the text file rendered into the new files, asking for a file if needed
+func (a *MainApp) SetFileTemplate(name string) {
+	switch name {
+	case emptyFiles:
+		a.Processor.FileTemplate = ""
+	case chooseFileText:
+		dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				// Go back to the text used so far
+				previous := a.Processor.FileTemplate
+				if previous == "" {
+					previous = emptyFiles
+				}
+				a.FileTextSelect.SetSelected(previous)
+				return
+			}
+			reader.Close()
+			file := LocalPath(reader.URI())
+			a.FileTextSelect.Options = fileTextChoices(file)
+			a.FileTextSelect.SetSelected(file)
+		}, a.Window).Show()
+		return
+	default:
+		text, err := os.ReadFile(name)
+		if err == nil {
+			_, err = ParseFileTemplate(string(text))
+		}
+		if err != nil {
+			a.StatusLabel.SetText(err.Error())
+			return
+		}
+		a.Processor.FileTemplate = name
+		a.StatusLabel.SetText("New files get the text of " + name)
+	}
+	a.RefreshFolderTree()
+}
+
+// Set the placeholder put into every empty folder
+func (a *MainApp) SetKeepFile(name string) {
+	if name == noKeepFile {
+		a.Processor.KeepFile = ""
+		return
+	}
+	a.Processor.KeepFile, _ = ParseKeepFile(name)
+}
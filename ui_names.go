@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Create the entry of the pattern naming the top-level folders
+func (a *MainApp) MakeNameEntry() *widget.Entry {
+	a.NameEntry = widget.NewEntry()
+	a.NameEntry.SetPlaceHolder(`Name pattern, e.g. {{.Col "A"}}_{{.Col "B" | upper}}`)
+	a.NameEntry.OnChanged = a.SetNamePattern
+	return a.NameEntry
+}
+
+// Set the pattern naming the top-level folders, keeping the last valid one while typing
+func (a *MainApp) SetNamePattern(text string) {
+	if strings.TrimSpace(text) == "" {
+		text = ""
+	} else if _, err := ParseNamePattern(text); err != nil {
+		a.StatusLabel.SetText(err.Error())
+		return
+	}
+	a.Processor.NamePattern = text
+	a.RefreshNames()
+	if text != "" {
+		a.StatusLabel.SetText("Top-level folders are named by the pattern")
+	}
+}
+
+// Render the names again after the table or its columns changed
+func (a *MainApp) RefreshNames() {
+	a.Names, _ = a.Processor.NewNamePreview()
+	if a.PreviewTable != nil {
+		a.AutoUpdateColumnWidths()
+		a.PreviewTable.Refresh()
+	}
+	a.RefreshFolderTree()
+}
+
+// Show the name rendered for the row in the name column
+func (a *MainApp) ShowRenderedName(row int, label *widget.Label) {
+	name := a.Names.Name(row)
+	switch {
+	case a.Processor.IsHeaderRow(row):
+		name = "Folder name"
+		label.TextStyle = fyne.TextStyle{Bold: true}
+	case strings.HasPrefix(name, "! "):
+		name = strings.TrimPrefix(name, "! ")
+		label.Importance = widget.DangerImportance
+	default:
+		label.Importance = widget.HighImportance
+	}
+	label.SetText(name)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Run work in the background behind a progress dialog with a Cancel button
+// done is called on the UI thread once work has returned, cancelled or not
+func (a *MainApp) RunWithProgress(title string, work func(ctx context.Context, report ProgressFunc), done func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	bar := widget.NewProgressBar()
+	info := widget.NewLabel("Starting...")
+	progressDialog := dialog.NewCustom(title, "Cancel", container.NewVBox(info, bar), a.Window)
+	progressDialog.SetOnClosed(func() {
+		info.SetText("Cancelling...")
+		cancel()
+	})
+	progressDialog.Resize(fyne.NewSize(450, 150))
+	progressDialog.Show()
+
+	report := func(pr Progress) {
+		fyne.Do(func() {
+			bar.SetValue(pr.Fraction())
+			info.SetText(pr.String())
+		})
+	}
+	go func() {
+		work(ctx, report)
+		fyne.Do(func() {
+			progressDialog.SetOnClosed(nil)
+			progressDialog.Hide()
+			cancel()
+			done()
+		})
+	}()
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ResultsPanel lists the folders that failed in the last run
+type ResultsPanel struct {
+	Container *fyne.Container
+	Title     *widget.Label
+	List      *widget.List
+	Result    *RunResult
+	failures  []CellResult
+	window    fyne.Window
+}
+
+// Create the results panel, hidden by default
+func NewResultsPanel(window fyne.Window) *ResultsPanel {
+	rp := &ResultsPanel{window: window}
+	rp.Title = widget.NewLabel("Failures:")
+	rp.List = widget.NewList(
+		func() int { return len(rp.failures) },
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			res := rp.failures[i]
+			label := o.(*widget.Label)
+			label.SetText(fmt.Sprintf("Row %d, column %s  [%s]  %s: %s",
+				res.Row+1, ColumnName(res.Col), res.Kind, res.Path, res.Error))
+			if res.Status == ResultPending {
+				label.SetText(fmt.Sprintf("Row %d, column %s  [pending]  %s", res.Row+1, ColumnName(res.Col), res.Path))
+			}
+		},
+	)
+	// Keep the list at a readable height
+	listSize := canvas.NewRectangle(color.Transparent)
+	listSize.SetMinSize(fyne.NewSize(0, 150))
+	exportButton := widget.NewButton("Export CSV", rp.Export)
+	hideButton := widget.NewButton("Hide", rp.Hide)
+	rp.Container = container.NewVBox(
+		widget.NewSeparator(),
+		container.NewHBox(rp.Title, layout.NewSpacer(), exportButton, hideButton),
+		container.NewStack(listSize, rp.List),
+	)
+	rp.Container.Hide()
+	return rp
+}
+
+// Show the failures of the result, or hide the panel if there are none
+func (rp *ResultsPanel) Show(result *RunResult) {
+	rp.Result = result
+	rp.failures = result.Failures()
+	if len(rp.failures) == 0 {
+		rp.Hide()
+		return
+	}
+	rp.Title.SetText(fmt.Sprintf("Failures: %d", len(rp.failures)))
+	rp.List.Refresh()
+	rp.List.ScrollToTop()
+	rp.Container.Show()
+}
+
+// Hide the panel
+func (rp *ResultsPanel) Hide() {
+	rp.Container.Hide()
+}
+
+// Save the failures to a CSV file chosen by the user
+func (rp *ResultsPanel) Export() {
+	if rp.Result == nil {
+		return
+	}
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, rp.window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+		if err := rp.Result.WriteFailuresCSV(writer); err != nil {
+			dialog.ShowError(err, rp.window)
+		}
+	}, rp.window)
+	saveDialog.SetFileName("failures.csv")
+	saveDialog.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+	saveDialog.Show()
+}
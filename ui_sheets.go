@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Ask which sheets to read when the workbook has more than one, then call load
+func (a *MainApp) PickSheets(filePath string, load func()) {
+	a.Processor.Sheets = nil
+	sheets, err := ListSheets(filePath)
+	if err != nil || len(sheets) < 2 {
+		// Let LoadFile report the error
+		load()
+		return
+	}
+
+	sheetChecks := widget.NewCheckGroup(sheets, nil)
+	sheetChecks.SetSelected(sheets[:1])
+	allCheck := widget.NewCheck("All sheets", func(checked bool) {
+		if checked {
+			sheetChecks.SetSelected(sheets)
+		} else {
+			sheetChecks.SetSelected(sheets[:1])
+		}
+	})
+	modeRadio := widget.NewRadioGroup(SheetModeNames, nil)
+	modeRadio.SetSelected(SheetModeNames[a.Processor.SheetMode])
+	modeRadio.Required = true
+
+	content := container.NewBorder(
+		container.NewVBox(widget.NewLabel("Sheets to read:"), allCheck),
+		container.NewVBox(widget.NewSeparator(), modeRadio),
+		nil,
+		nil,
+		container.NewVScroll(sheetChecks),
+	)
+	sheetDialog := dialog.NewCustomConfirm("Select Sheets", "Load", "Cancel", content, func(ok bool) {
+		if !ok {
+			a.StatusLabel.SetText("Loading cancelled")
+			return
+		}
+		if len(sheetChecks.Selected) == 0 {
+			a.StatusLabel.SetText("Select at least one sheet!")
+			return
+		}
+		// Keep the workbook order whatever the click order was
+		for _, name := range sheets {
+			for _, selected := range sheetChecks.Selected {
+				if name == selected {
+					a.Processor.Sheets = append(a.Processor.Sheets, name)
+				}
+			}
+		}
+		a.Processor.SheetMode = SheetMerge
+		if modeRadio.Selected == SheetModeNames[SheetFolders] {
+			a.Processor.SheetMode = SheetFolders
+		}
+		load()
+	}, a.Window)
+	sheetDialog.Resize(fyne.NewSize(400, 450))
+	sheetDialog.Show()
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// SyncPanel compares the table with the folders already under the target path
+type SyncPanel struct {
+	Container     *fyne.Container
+	Summary       *widget.Label
+	List          *widget.List
+	HidePresent   *widget.Check
+	ArchiveButton *widget.Button
+	Report        *SyncReport
+	shown         []SyncEntry
+}
+
+// Create the sync panel, empty until the folders are compared
+func (a *MainApp) NewSyncPanel() *SyncPanel {
+	sp := &SyncPanel{}
+	sp.Summary = widget.NewLabel("")
+	sp.Summary.Wrapping = fyne.TextWrapWord
+	sp.List = widget.NewList(
+		func() int { return len(sp.shown) },
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			entry := sp.shown[i]
+			text := fmt.Sprintf("[%s]  %s", entry.Status, sp.Report.RelPath(entry))
+			if entry.Row >= 0 {
+				text = fmt.Sprintf("Row %d  %s", entry.Row+1, text)
+			}
+			label.SetText(text)
+			switch entry.Status {
+			case SyncMissing:
+				label.Importance = widget.WarningImportance
+			case SyncUnexpected:
+				label.Importance = widget.DangerImportance
+			default:
+				label.Importance = widget.MediumImportance
+			}
+			label.Refresh()
+		},
+	)
+	sp.ArchiveButton = widget.NewButton("Archive Unexpected", a.ArchiveUnexpected)
+	sp.ArchiveButton.Disable()
+	sp.HidePresent = widget.NewCheck("Hide present folders", func(bool) { sp.Show(sp.Report) })
+	sp.HidePresent.SetChecked(true)
+	sp.Container = container.NewBorder(
+		container.NewVBox(
+			container.NewHBox(
+				widget.NewButton("Compare", a.CompareTree),
+				sp.HidePresent,
+				layout.NewSpacer(),
+				sp.ArchiveButton,
+			),
+			sp.Summary,
+		),
+		nil, nil, nil,
+		sp.List,
+	)
+	return sp
+}
+
+// Show the entries of the report, nil to empty the panel
+func (sp *SyncPanel) Show(report *SyncReport) {
+	sp.Report = report
+	sp.shown = nil
+	if report == nil {
+		sp.Summary.SetText("Compare the table with the folders already in the target path")
+		sp.ArchiveButton.Disable()
+		sp.List.Refresh()
+		return
+	}
+	for _, entry := range report.Entries {
+		if entry.Status != SyncPresent || !sp.HidePresent.Checked {
+			sp.shown = append(sp.shown, entry)
+		}
+	}
+	sp.Summary.SetText(report.Summary())
+	if report.Count(SyncUnexpected) > 0 {
+		sp.ArchiveButton.Enable()
+	} else {
+		sp.ArchiveButton.Disable()
+	}
+	sp.List.Refresh()
+	sp.List.ScrollToTop()
+}
+
+// Plan the folders and compare them with the target path in the background
+func (a *MainApp) CompareTree() {
+	if !a.CheckReady() {
+		return
+	}
+	processor := a.Processor
+	var report *SyncReport
+	var err error
+	a.RunWithProgress("Comparing Folders", func(ctx context.Context, progress ProgressFunc) {
+		plan, planErr := processor.BuildPlanContext(ctx, progress)
+		if planErr != nil {
+			err = planErr
+			return
+		}
+		report, err = CompareTree(ctx, plan)
+	}, func() {
+		if err != nil {
+			a.StatusLabel.SetText("Error: " + err.Error())
+			return
+		}
+		a.Sync.Show(report)
+		a.StatusLabel.SetText("Compared with the target path: " + report.Summary())
+	})
+}
+
+// Move the unexpected folders into the archive after asking the user
+func (a *MainApp) ArchiveUnexpected() {
+	report := a.Sync.Report
+	if report == nil || report.Count(SyncUnexpected) == 0 {
+		return
+	}
+	message := fmt.Sprintf("Move the %d unexpected folder(s) with everything inside them into\n%s?\n\nNothing is deleted.",
+		report.Count(SyncUnexpected), ArchiveFolderName+"/"+time.Now().Format("2006-01-02"))
+	dialog.ShowConfirm("Archive Unexpected", message, func(ok bool) {
+		if !ok {
+			return
+		}
+		result := report.ArchiveUnexpected(time.Now())
+		a.RefreshFolderTree()
+		a.Sync.Show(report)
+		if len(result.Errors) > 0 {
+			dialog.ShowError(fmt.Errorf("%d folder(s) not archived: %v", len(result.Errors), result.Errors[0]), a.Window)
+		}
+		a.StatusLabel.SetText("Archive: " + result.Summary())
+	}, a.Window)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Choices of the template select besides the templates themselves
+const (
+	noTemplate     = "No template"
+	chooseTemplate = "Choose folder..."
+)
+
+// Create the controls choosing the template copied into each top-level folder
+func (a *MainApp) MakeTemplateRow() fyne.CanvasObject {
+	a.TemplateSelect = widget.NewSelect(templateChoices(""), nil)
+	a.TemplateSelect.SetSelected(noTemplate)
+	a.TemplateSelect.OnChanged = a.SetTemplate
+	a.ConflictSelect = widget.NewSelect(TemplateConflictNames, func(name string) {
+		a.Processor.TemplateConflict, _ = ParseTemplateConflict(name)
+	})
+	a.ConflictSelect.SetSelected(TemplateConflictNames[ConflictSkip])
+	return container.NewBorder(nil, nil,
+		container.NewHBox(
+			widget.NewLabel("Template:"),
+			a.TemplateSelect,
+			a.ConflictSelect,
+			widget.NewLabel("Names:"),
+		),
+		nil,
+		a.MakeNameEntry(),
+	)
+}
+
+// Choices of the template select, with the chosen template folder if any
+func templateChoices(folder string) []string {
+	choices := append([]string{noTemplate}, BundledTemplateNames()...)
+	if folder != "" {
+		choices = append(choices, folder)
+	}
+	return append(choices, chooseTemplate)
+}
+
+// Set the template copied into each top-level folder, asking for a folder if needed
+func (a *MainApp) SetTemplate(name string) {
+	switch name {
+	case noTemplate:
+		a.Processor.Template = ""
+		a.RefreshFolderTree()
+	case chooseTemplate:
+		dialog.NewFolderOpen(func(list fyne.ListableURI, err error) {
+			if err != nil || list == nil {
+				// Go back to the template used so far
+				previous := a.Processor.Template
+				if previous == "" {
+					previous = noTemplate
+				}
+				a.TemplateSelect.SetSelected(previous)
+				return
+			}
+			folder := LocalPath(list)
+			a.TemplateSelect.Options = templateChoices(folder)
+			a.TemplateSelect.SetSelected(folder)
+		}, a.Window).Show()
+		return
+	default:
+		a.Processor.Template = name
+		a.RefreshFolderTree()
+		a.StatusLabel.SetText("Template: " + name)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// FolderTreePanel shows the folders the plan leads to under the target path
+type FolderTreePanel struct {
+	Container *fyne.Container
+	Summary   *widget.Label
+	Tree      *widget.Tree
+	folders   *FolderTree
+	cancel    context.CancelFunc // Stops the planning in progress
+	run       int                // Number of the latest planning, older results are dropped
+}
+
+// Create the folder tree panel, empty until a plan is shown
+func NewFolderTreePanel() *FolderTreePanel {
+	tp := &FolderTreePanel{folders: &FolderTree{}}
+	tp.Summary = widget.NewLabel("")
+	tp.Summary.Wrapping = fyne.TextWrapWord
+	// Read the tree of the latest plan
+	tp.Tree = widget.NewTree(
+		func(id widget.TreeNodeID) []widget.TreeNodeID { return tp.folders.ChildIDs(id) },
+		func(id widget.TreeNodeID) bool { return tp.folders.IsBranch(id) },
+		func(bool) fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.TreeNodeID, _ bool, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			node := tp.folders.Nodes[id]
+			if node == nil {
+				label.SetText("")
+				return
+			}
+			text := node.Name
+			switch {
+			case node.Kind == OpCreate && node.File:
+				text += "  [new file]"
+				label.Importance = widget.SuccessImportance
+			case node.Kind == OpCreate:
+				text += "  [new]"
+				label.Importance = widget.SuccessImportance
+			case node.Kind == OpInvalid || node.Kind == OpRejected:
+				text += fmt.Sprintf("  [%s: %s]", node.Kind, node.Reason)
+				label.Importance = widget.DangerImportance
+			default:
+				if node.Row >= 0 {
+					text += "  [exists]"
+				}
+				label.Importance = widget.MediumImportance
+			}
+			label.SetText(text)
+		},
+	)
+	tp.Container = container.NewBorder(tp.Summary, nil, nil, nil, tp.Tree)
+	return tp
+}
+
+// Empty the tree and show why
+func (tp *FolderTreePanel) Clear(message string) {
+	if tp.cancel != nil {
+		tp.cancel()
+	}
+	tp.run++
+	tp.folders = &FolderTree{}
+	tp.Summary.SetText(message)
+	tp.Tree.Refresh()
+}
+
+// Plan the folders of the table in the background and show them
+// A planning still in progress is cancelled.
+func (tp *FolderTreePanel) Plan(p *FileProcessor) {
+	if tp.cancel != nil {
+		tp.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	tp.cancel = cancel
+	tp.run++
+	run := tp.run
+	// Plan with a copy, the table and the options may change while it runs
+	snapshot := p.Snapshot()
+	tp.Summary.SetText("Planning...")
+	go func() {
+		plan, err := snapshot.BuildPlanContext(ctx, nil)
+		fyne.Do(func() {
+			cancel()
+			if run != tp.run {
+				return
+			}
+			if err != nil {
+				tp.Clear("Error: " + err.Error())
+				return
+			}
+			tp.folders = NewFolderTree(plan)
+			tp.Summary.SetText(plan.Summary())
+			tp.Tree.Refresh()
+			tp.Tree.OpenBranch(".")
+		})
+	}()
+}
@@ -0,0 +1,502 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+)
+
+// Record types of the BIFF8 format read by the xls reader
+const (
+	xlsBOF         = 0x0809
+	xlsEOF         = 0x000A
+	xlsContinue    = 0x003C
+	xlsFilePass    = 0x002F
+	xlsDateMode    = 0x0022
+	xlsBoundSheet  = 0x0085
+	xlsSST         = 0x00FC
+	xlsFormat      = 0x041E
+	xlsXF          = 0x00E0
+	xlsLabelSST    = 0x00FD
+	xlsLabel       = 0x0204
+	xlsNumber      = 0x0203
+	xlsRK          = 0x027E
+	xlsMulRK       = 0x00BD
+	xlsFormula     = 0x0006
+	xlsString      = 0x0207
+	xlsBoolErr     = 0x0205
+	xlsBIFF8       = 0x0600
+	xlsSheetWorker = 0 // Sheet type of a worksheet in BOUNDSHEET
+)
+
+// Error values of BOOLERR and FORMULA cells
+var xlsErrors = map[byte]string{
+	0x00: "#NULL!", 0x07: "#DIV/0!", 0x0F: "#VALUE!", 0x17: "#REF!",
+	0x1D: "#NAME?", 0x24: "#NUM!", 0x2A: "#N/A",
+}
+
+// xlsWorkbook is the workbook stream of an Excel 97-2003 file
+type xlsWorkbook struct {
+	data     []byte
+	sheets   []xlsSheet
+	strings  []string          // Shared strings
+	xfs      []uint16          // Number format of each cell format
+	formats  map[uint16]string // Custom number formats
+	date1904 bool
+}
+
+// xlsSheet is a worksheet and where its records start
+type xlsSheet struct {
+	name   string
+	offset int
+}
+
+// load XLS file, reading the selected sheets
+func (p *FileProcessor) ReadXLSFile(filePath string) ([][]string, error) {
+	wb, err := openXLS(filePath)
+	if err != nil {
+		return nil, err
+	}
+	sheets, err := p.SelectSheets(wb.sheetNames())
+	if err != nil {
+		return nil, err
+	}
+	// Read all rows from each sheet
+	var data [][]string
+	for _, sheetName := range sheets {
+		rows, err := wb.rows(sheetName)
+		if err != nil {
+			return nil, err
+		}
+		p.SheetRanges = append(p.SheetRanges, SheetRange{Name: sheetName, Start: len(data)})
+		data = append(data, rows...)
+	}
+	return PadRows(data), nil
+}
+
+// Names of the worksheets in an XLS file
+func xlsSheetNames(filePath string) ([]string, error) {
+	wb, err := openXLS(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return wb.sheetNames(), nil
+}
+
+// Read the workbook stream of the file and its global records
+func openXLS(filePath string) (*xlsWorkbook, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	doc, err := mscfb.New(file)
+	if err != nil {
+		return nil, fmt.Errorf("not an Excel 97-2003 workbook: %v", err)
+	}
+	wb := &xlsWorkbook{formats: make(map[uint16]string)}
+	for entry, err := doc.Next(); err == nil; entry, err = doc.Next() {
+		switch entry.Name {
+		case "Workbook":
+			if wb.data, err = io.ReadAll(entry); err != nil {
+				return nil, err
+			}
+		case "Book":
+			return nil, fmt.Errorf("workbooks older than Excel 97 are not supported")
+		}
+	}
+	if wb.data == nil {
+		return nil, fmt.Errorf("not an Excel workbook: %s", filePath)
+	}
+	if err := wb.readGlobals(); err != nil {
+		return nil, err
+	}
+	return wb, nil
+}
+
+// Read the records before the first sheet
+func (wb *xlsWorkbook) readGlobals() error {
+	var sst [][]byte // SST record and its CONTINUE records
+	var prev uint16
+	for pos := 0; ; {
+		typ, body, next, err := wb.record(pos)
+		if err != nil {
+			return err
+		}
+		if pos == 0 && (typ != xlsBOF || len(body) < 2 || binary.LittleEndian.Uint16(body) != xlsBIFF8) {
+			return fmt.Errorf("workbooks older than Excel 97 are not supported")
+		}
+		switch typ {
+		case xlsEOF:
+			wb.strings = readSST(sst)
+			return nil
+		case xlsFilePass:
+			return fmt.Errorf("password protected workbooks are not supported")
+		case xlsDateMode:
+			wb.date1904 = len(body) >= 2 && body[0] == 1
+		case xlsBoundSheet:
+			if len(body) >= 8 && body[5] == xlsSheetWorker {
+				name, _ := readXLSString(body[6:], 1)
+				wb.sheets = append(wb.sheets, xlsSheet{name: name, offset: int(binary.LittleEndian.Uint32(body))})
+			}
+		case xlsSST:
+			sst = [][]byte{body}
+		case xlsContinue:
+			// Only the records right after the SST continue it
+			if prev == xlsSST {
+				sst = append(sst, body)
+				typ = xlsSST
+			}
+		case xlsFormat:
+			if len(body) >= 5 {
+				format, _ := readXLSString(body[2:], 2)
+				wb.formats[binary.LittleEndian.Uint16(body)] = format
+			}
+		case xlsXF:
+			if len(body) >= 4 {
+				wb.xfs = append(wb.xfs, binary.LittleEndian.Uint16(body[2:]))
+			}
+		}
+		prev = typ
+		pos = next
+	}
+}
+
+// Record at pos: its type, its body and where the next one starts
+func (wb *xlsWorkbook) record(pos int) (uint16, []byte, int, error) {
+	if pos+4 > len(wb.data) {
+		return 0, nil, 0, fmt.Errorf("damaged workbook: record past the end of the file")
+	}
+	typ := binary.LittleEndian.Uint16(wb.data[pos:])
+	size := int(binary.LittleEndian.Uint16(wb.data[pos+2:]))
+	end := pos + 4 + size
+	if end > len(wb.data) {
+		return 0, nil, 0, fmt.Errorf("damaged workbook: record past the end of the file")
+	}
+	return typ, wb.data[pos+4 : end], end, nil
+}
+
+// Names of the worksheets, in workbook order
+func (wb *xlsWorkbook) sheetNames() []string {
+	names := make([]string, len(wb.sheets))
+	for i, sheet := range wb.sheets {
+		names[i] = sheet.name
+	}
+	return names
+}
+
+// Size of a BIFF8 worksheet
+const (
+	xlsMaxRows = 65536
+	xlsMaxCols = 256
+)
+
+// Read the rows of a sheet like GetRows does for xlsx:
+// empty rows only when a filled row follows and no empty cells at the end of a row
+func (wb *xlsWorkbook) rows(sheetName string) ([][]string, error) {
+	pos := -1
+	for _, sheet := range wb.sheets {
+		if sheet.name == sheetName {
+			pos = sheet.offset
+		}
+	}
+	if pos < 0 {
+		return nil, fmt.Errorf("sheet not found: %s", sheetName)
+	}
+	var rows [][]string
+	set := func(row, col int, value string) {
+		// Cells beyond the sheet size of Excel 97-2003 only come from broken files
+		if value == "" || row >= xlsMaxRows || col >= xlsMaxCols {
+			return
+		}
+		for len(rows) <= row {
+			rows = append(rows, []string{})
+		}
+		for len(rows[row]) <= col {
+			rows[row] = append(rows[row], "")
+		}
+		rows[row][col] = value
+	}
+	formulaRow, formulaCol := -1, -1 // Cell waiting for the string result of its formula
+	for {
+		typ, body, next, err := wb.record(pos)
+		if err != nil {
+			return nil, fmt.Errorf("sheet %s: %v", sheetName, err)
+		}
+		pos = next
+		if typ == xlsEOF {
+			return rows, nil
+		}
+		if typ == xlsString && formulaRow >= 0 {
+			text, _ := readXLSString(body, 2)
+			set(formulaRow, formulaCol, text)
+			formulaRow, formulaCol = -1, -1
+		}
+		if len(body) < 6 {
+			continue
+		}
+		row := int(binary.LittleEndian.Uint16(body))
+		col := int(binary.LittleEndian.Uint16(body[2:]))
+		xf := binary.LittleEndian.Uint16(body[4:])
+		switch typ {
+		case xlsLabelSST:
+			if len(body) >= 10 {
+				if i := int(binary.LittleEndian.Uint32(body[6:])); i < len(wb.strings) {
+					set(row, col, wb.strings[i])
+				}
+			}
+		case xlsLabel:
+			text, _ := readXLSString(body[6:], 2)
+			set(row, col, text)
+		case xlsNumber:
+			if len(body) >= 14 {
+				set(row, col, wb.formatNumber(math.Float64frombits(binary.LittleEndian.Uint64(body[6:])), xf))
+			}
+		case xlsRK:
+			if len(body) >= 10 {
+				set(row, col, wb.formatNumber(decodeRK(binary.LittleEndian.Uint32(body[6:])), xf))
+			}
+		case xlsMulRK:
+			// Pairs of format and value, then the last column
+			for i := 4; i+6 <= len(body)-2; i += 6 {
+				xf := binary.LittleEndian.Uint16(body[i:])
+				set(row, col, wb.formatNumber(decodeRK(binary.LittleEndian.Uint32(body[i+2:])), xf))
+				col++
+			}
+		case xlsFormula:
+			if len(body) < 14 {
+				continue
+			}
+			result := body[6:14]
+			if result[6] != 0xFF || result[7] != 0xFF {
+				set(row, col, wb.formatNumber(math.Float64frombits(binary.LittleEndian.Uint64(result)), xf))
+				continue
+			}
+			switch result[0] {
+			case 0: // String, in the next STRING record
+				formulaRow, formulaCol = row, col
+			case 1:
+				set(row, col, formatXLSBool(result[2]))
+			case 2:
+				set(row, col, xlsErrors[result[2]])
+			}
+		case xlsBoolErr:
+			if len(body) >= 8 {
+				if body[7] == 1 {
+					set(row, col, xlsErrors[body[6]])
+				} else {
+					set(row, col, formatXLSBool(body[6]))
+				}
+			}
+		}
+	}
+}
+
+// Text of a TRUE or FALSE cell
+func formatXLSBool(value byte) string {
+	if value != 0 {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// Decode a compressed RK number
+func decodeRK(rk uint32) float64 {
+	var value float64
+	if rk&2 != 0 {
+		value = float64(int32(rk) >> 2)
+	} else {
+		value = math.Float64frombits(uint64(rk&^3) << 32)
+	}
+	if rk&1 != 0 {
+		value /= 100
+	}
+	return value
+}
+
+// Show a number the way it is usually read: dates as dates, other numbers in full
+func (wb *xlsWorkbook) formatNumber(value float64, xf uint16) string {
+	if int(xf) < len(wb.xfs) && isDateFormat(wb.xfs[xf], wb.formats[wb.xfs[xf]]) && value >= 0 {
+		base := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+		if wb.date1904 {
+			base = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+		}
+		day := math.Floor(value)
+		t := base.AddDate(0, 0, int(day)).Add(time.Duration(math.Round((value-day)*86400)) * time.Second)
+		switch {
+		case day == 0 && value != day:
+			return t.Format("15:04:05")
+		case value == day:
+			return t.Format("2006-01-02")
+		}
+		return t.Format("2006-01-02 15:04:05")
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// Does the number format show a date or a time
+func isDateFormat(id uint16, format string) bool {
+	// Built-in date and time formats
+	if (id >= 14 && id <= 22) || (id >= 45 && id <= 47) {
+		return true
+	}
+	// Look for date parts outside quoted text and [colors]
+	quoted, bracket := false, false
+	for _, r := range strings.ToLower(format) {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '[':
+			bracket = true
+		case r == ']':
+			bracket = false
+		case bracket:
+		case strings.ContainsRune("ymdhs", r):
+			return true
+		}
+	}
+	return false
+}
+
+// Read a string with a length of size bytes, then option flags and the characters
+// Returns the string and the bytes it used
+func readXLSString(b []byte, size int) (string, int) {
+	if len(b) < size+1 {
+		return "", len(b)
+	}
+	n := int(b[0])
+	if size == 2 {
+		n = int(binary.LittleEndian.Uint16(b))
+	}
+	flags := b[size]
+	pos := size + 1
+	if flags&0x08 != 0 {
+		pos += 2 // Rich text runs
+	}
+	if flags&0x04 != 0 {
+		pos += 4 // Phonetic data
+	}
+	width := 1
+	if flags&0x01 != 0 {
+		width = 2
+	}
+	end := min(pos+n*width, len(b))
+	if pos > end {
+		return "", len(b)
+	}
+	return decodeXLSChars(b[pos:end], width == 2), end
+}
+
+// Turn compressed (Latin-1) or UTF-16 characters into a string
+func decodeXLSChars(b []byte, wide bool) string {
+	if !wide {
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			runes[i] = rune(c)
+		}
+		return string(runes)
+	}
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// sstReader reads the shared strings across the SST record and its CONTINUE records
+type sstReader struct {
+	parts [][]byte
+	part  int
+	pos   int
+}
+
+// Are there bytes left to read
+func (r *sstReader) more() bool {
+	for r.part < len(r.parts) && r.pos >= len(r.parts[r.part]) {
+		r.part++
+		r.pos = 0
+	}
+	return r.part < len(r.parts)
+}
+
+// Read n bytes, crossing into the next record when needed
+func (r *sstReader) bytes(n int) []byte {
+	var b []byte
+	for n > 0 && r.more() {
+		part := r.parts[r.part][r.pos:]
+		k := min(n, len(part))
+		b = append(b, part[:k]...)
+		r.pos += k
+		n -= k
+	}
+	return b
+}
+
+// Read n characters; a string continued in the next record restarts with new flags
+func (r *sstReader) chars(n int, wide bool) string {
+	var text strings.Builder
+	for first := true; n > 0; first = false {
+		if !first {
+			r.part++
+			if r.part >= len(r.parts) || len(r.parts[r.part]) == 0 {
+				break
+			}
+			wide = r.parts[r.part][0]&0x01 != 0
+			r.pos = 1
+		}
+		if r.part >= len(r.parts) {
+			break
+		}
+		width := 1
+		if wide {
+			width = 2
+		}
+		part := r.parts[r.part][r.pos:]
+		k := min(n, len(part)/width)
+		text.WriteString(decodeXLSChars(part[:k*width], wide))
+		r.pos += k * width
+		n -= k
+	}
+	return text.String()
+}
+
+// Read the shared strings of the workbook
+func readSST(parts [][]byte) []string {
+	if len(parts) == 0 || len(parts[0]) < 8 {
+		return []string{}
+	}
+	count := int(binary.LittleEndian.Uint32(parts[0][4:]))
+	r := &sstReader{parts: parts, pos: 8}
+	list := make([]string, 0, min(count, 1<<16))
+	for len(list) < count && r.more() {
+		header := r.bytes(3)
+		if len(header) < 3 {
+			break
+		}
+		n := int(binary.LittleEndian.Uint16(header))
+		flags := header[2]
+		runs, phonetic := 0, 0
+		if flags&0x08 != 0 {
+			if b := r.bytes(2); len(b) == 2 {
+				runs = int(binary.LittleEndian.Uint16(b))
+			}
+		}
+		if flags&0x04 != 0 {
+			if b := r.bytes(4); len(b) == 4 {
+				phonetic = int(binary.LittleEndian.Uint32(b))
+			}
+		}
+		list = append(list, r.chars(n, flags&0x01 != 0))
+		r.bytes(4*runs + phonetic)
+	}
+	return list
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+// Start of an SST record listing count strings
+func sstHeader(count int) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint32(b, uint32(count))
+	binary.LittleEndian.PutUint32(b[4:], uint32(count))
+	return b
+}
+
+// Character count and option flags of a shared string
+func sstString(n int, flags byte) []byte {
+	return []byte{byte(n), byte(n >> 8), flags}
+}
+
+// Characters of a string stored as UTF-16
+func wideChars(s string) []byte {
+	var b []byte
+	for _, unit := range utf16.Encode([]rune(s)) {
+		b = binary.LittleEndian.AppendUint16(b, unit)
+	}
+	return b
+}
+
+// Join byte slices into one record part
+func sstPart(pieces ...[]byte) []byte {
+	var b []byte
+	for _, piece := range pieces {
+		b = append(b, piece...)
+	}
+	return b
+}
+
+func TestReadSST(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts [][]byte
+		want  []string
+	}{
+		{"no record", nil, []string{}},
+		{"cut record", [][]byte{{1, 0, 0}}, []string{}},
+		{"one record", [][]byte{sstPart(
+			sstHeader(3),
+			sstString(5, 0x00), []byte("Alpha"),
+			sstString(2, 0x01), wideChars("项目"),
+			sstString(0, 0x00),
+		)}, []string{"Alpha", "项目", ""}},
+		{"string starting in a continue record", [][]byte{
+			sstPart(sstHeader(2), sstString(3, 0x00), []byte("One")),
+			sstPart(sstString(3, 0x00), []byte("Two")),
+		}, []string{"One", "Two"}},
+		{"characters split across records", [][]byte{
+			sstPart(sstHeader(2), sstString(6, 0x00), []byte("Fol")),
+			sstPart([]byte{0x00}, []byte("der"), sstString(1, 0x00), []byte("X")),
+		}, []string{"Folder", "X"}},
+		{"compressed then wide", [][]byte{
+			sstPart(sstHeader(1), sstString(4, 0x00), []byte("Ab")),
+			sstPart([]byte{0x01}, wideChars("çé")),
+		}, []string{"Abçé"}},
+		{"wide then compressed", [][]byte{
+			sstPart(sstHeader(1), sstString(4, 0x01), wideChars("项目")),
+			sstPart([]byte{0x00}, []byte("01")),
+		}, []string{"项目01"}},
+		{"split over three records", [][]byte{
+			sstPart(sstHeader(1), sstString(5, 0x00), []byte("a")),
+			sstPart([]byte{0x00}, []byte("bc")),
+			sstPart([]byte{0x01}, wideChars("dé")),
+		}, []string{"abcdé"}},
+		{"rich text runs in the next record", [][]byte{
+			sstPart(sstHeader(2), sstString(4, 0x08), []byte{2, 0}, []byte("Bold"), make([]byte, 4)),
+			sstPart(make([]byte, 4), sstString(4, 0x00), []byte("Next")),
+		}, []string{"Bold", "Next"}},
+		{"phonetic data", [][]byte{sstPart(
+			sstHeader(2),
+			sstString(2, 0x04), []byte{3, 0, 0, 0}, []byte("Ka"), []byte{1, 2, 3},
+			sstString(1, 0x00), []byte("Z"),
+		)}, []string{"Ka", "Z"}},
+		{"fewer strings than counted", [][]byte{
+			sstPart(sstHeader(3), sstString(1, 0x00), []byte("A")),
+		}, []string{"A"}},
+		{"string cut at the last record", [][]byte{
+			sstPart(sstHeader(1), sstString(6, 0x00), []byte("Fol")),
+		}, []string{"Fol"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readSST(tt.parts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}